@@ -5,7 +5,12 @@ author: Jonas Yakimischak
 */
 package actfuncs
 
-import "math"
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+)
 
 //NoActFunc no activation function
 const NoActFunc = "noActFunc"
@@ -16,22 +21,204 @@ const Step = "step"
 //Sigmoid activation function
 const Sigmoid = "sigmoid"
 
+//ReLU rectified linear unit activation function
+const ReLU = "relu"
+
+//LeakyReLU leaky rectified linear unit activation function, the slope for negative x is its alpha, see LookupAlpha
+const LeakyReLU = "leakyRelu"
+
+//ELU exponential linear unit activation function, the scale for negative x is its alpha, see LookupAlpha
+const ELU = "elu"
+
+//Tanh hyperbolic tangent activation function
+const Tanh = "tanh"
+
+//Softplus activation function
+const Softplus = "softplus"
+
+//GELU Gaussian error linear unit activation function
+const GELU = "gelu"
+
+//Swish activation function, also known as SiLU
+const Swish = "swish"
+
+//SiLU sigmoid linear unit activation function, the same function as Swish
+const SiLU = Swish
+
+//Softmax activation function.  Unlike the others it is applied across a whole layer with ApplySoftmax, not per value.
+const Softmax = "softmax"
+
+//DefaultLeakyReLUSlope the slope LeakyReLU uses for negative x when no alpha is given
+const DefaultLeakyReLUSlope = 0.01
+
+//DefaultELUAlpha the scale ELU uses for negative x when no alpha is given
+const DefaultELUAlpha = 1.0
+
+// Func is an activation function, or the derivative of one, applied to a single value.
+type Func func(x float64) float64
+
+// actFunc is a registered activation function and its derivative.
+type actFunc struct {
+	forward    Func
+	derivative Func
+}
+
+// registry holds every activation function by name.  It is guarded by registryMu so Register can be called from
+// concurrent init code.
+var registryMu sync.RWMutex
+var registry = map[string]actFunc{
+	NoActFunc: {forward: calcIdentity, derivative: calcIdentityDerivative},
+	Step:      {forward: calcStep, derivative: calcStepDerivative},
+	Sigmoid:   {forward: calcSigmoid, derivative: calcSigmoidDerivative},
+	ReLU:      {forward: calcReLU, derivative: calcReLUDerivative},
+	LeakyReLU: leakyReLU(DefaultLeakyReLUSlope),
+	ELU:       elu(DefaultELUAlpha),
+	Tanh:      {forward: math.Tanh, derivative: calcTanhDerivative},
+	Softplus:  {forward: calcSoftplus, derivative: calcSigmoid},
+	GELU:      {forward: calcGELU, derivative: calcGELUDerivative},
+	Swish:     {forward: calcSwish, derivative: calcSwishDerivative},
+}
+
+// Register will add a new activation function under the given name so it can be used anywhere a built in activation
+// function can.  The derivative must be the derivative of forward with respect to x, it is used for training.  An
+// error is returned if the name is empty or already registered, or if either function is nil.
+func Register(name string, forward Func, derivative Func) error {
+	if name == "" {
+		return errors.New("Register: name must not be empty")
+	}
+	if name == Softmax {
+		return fmt.Errorf("Register: activation function is already registered: %s", name)
+	}
+	if forward == nil || derivative == nil {
+		return fmt.Errorf("Register: forward and derivative must not be nil for activation function: %s", name)
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, exists := registry[name]; exists {
+		return fmt.Errorf("Register: activation function is already registered: %s", name)
+	}
+	registry[name] = actFunc{forward: forward, derivative: derivative}
+	return nil
+}
+
+// Lookup will return the activation function and its derivative registered under the given name.  It is useful when
+// the same activation function is applied many times, since the registry is only consulted once.
+func Lookup(name string) (forward Func, derivative Func, ok bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	af, ok := registry[name]
+	return af.forward, af.derivative, ok
+}
+
+// DefaultAlpha will return the alpha the activation function uses when none is given, DefaultLeakyReLUSlope for
+// LeakyReLU and DefaultELUAlpha for ELU.  The other activation functions have no alpha and return 0.
+func DefaultAlpha(actFunc string) float64 {
+	switch actFunc {
+	case LeakyReLU:
+		return DefaultLeakyReLUSlope
+	case ELU:
+		return DefaultELUAlpha
+	}
+	return 0
+}
+
+// LookupAlpha will return the activation function and its derivative like Lookup, with the given alpha for LeakyReLU
+// and ELU.  An alpha of 0 uses the default, and the alpha is ignored by activation functions that have none.
+func LookupAlpha(name string, alpha float64) (forward Func, derivative Func, ok bool) {
+	if alpha == 0 || alpha == DefaultAlpha(name) {
+		return Lookup(name)
+	}
+	switch name {
+	case LeakyReLU:
+		af := leakyReLU(alpha)
+		return af.forward, af.derivative, true
+	case ELU:
+		af := elu(alpha)
+		return af.forward, af.derivative, true
+	}
+	return Lookup(name)
+}
+
 // IsValidActFunc will return true if the given string is a valid activation function.
 func IsValidActFunc(actFunc string) bool {
-	return actFunc == NoActFunc || actFunc == Step || actFunc == Sigmoid
+	if actFunc == Softmax {
+		return true
+	}
+	_, _, ok := Lookup(actFunc)
+	return ok
 }
 
 // ApplyActFunc will apply the given activation function and return the value.  If the activation function is unknown (or nil) then
-// the x value will be returned without being modified.
+// the x value will be returned without being modified.  Softmax can not be applied to a single value so it is also
+// returned unmodified, use ApplySoftmax.
 func ApplyActFunc(actFunc string, x float64) float64 {
-	switch actFunc {
-	case Step:
-		return calcStep(x)
-	case Sigmoid:
-		return calcSigmoid(x)
-	default:
+	forward, _, ok := Lookup(actFunc)
+	if !ok {
 		return x
 	}
+	return forward(x)
+}
+
+// ApplyActFuncDerivative will return the derivative of the given activation function at x, where x is the value the
+// activation function was applied to.  If the activation function is unknown (or nil) then the derivative of the
+// identity, 1, will be returned.  Softmax also returns 1 since its Jacobian is applied across the layer with
+// ApplySoftmaxJacobian.
+//
+// The true derivative of Step is 0 everywhere it is defined, which would stop any gradient from flowing through it.
+// Step uses a straight-through surrogate gradient instead: it is differentiated as if it were the identity, so the
+// derivative is always 1.
+func ApplyActFuncDerivative(actFunc string, x float64) float64 {
+	_, derivative, ok := Lookup(actFunc)
+	if !ok {
+		return 1
+	}
+	return derivative(x)
+}
+
+// ApplySoftmax will apply the softmax activation function across all of xs and write the results to out, which must be
+// the same length as xs and may be xs itself.  The largest x is subtracted before exponentiating so large values do
+// not overflow.
+func ApplySoftmax(xs []float64, out []float64) {
+	if len(xs) == 0 {
+		return
+	}
+	max := xs[0]
+	for _, x := range xs {
+		if x > max {
+			max = x
+		}
+	}
+	sum := 0.0
+	for i, x := range xs {
+		out[i] = math.Exp(x - max)
+		sum += out[i]
+	}
+	for i := range out {
+		out[i] /= sum
+	}
+}
+
+// ApplySoftmaxJacobian will multiply the gradients with respect to the softmax outputs by the softmax Jacobian and write
+// the gradients with respect to the softmax inputs to out.  outputs are the values returned by ApplySoftmax.
+func ApplySoftmaxJacobian(outputs []float64, grads []float64, out []float64) {
+	dot := 0.0
+	for i := range outputs {
+		dot += grads[i] * outputs[i]
+	}
+	for i := range outputs {
+		out[i] = outputs[i] * (grads[i] - dot)
+	}
+}
+
+// calcIdentity calculate the identity, used for no activation function
+func calcIdentity(x float64) float64 {
+	return x
+}
+
+// calcIdentityDerivative calculate the derivative of the identity
+func calcIdentityDerivative(x float64) float64 {
+	return 1
 }
 
 // calcStep calculate step activation function
@@ -42,7 +229,108 @@ func calcStep(x float64) float64 {
 	return 0
 }
 
+// calcStepDerivative calculate the straight-through surrogate derivative of the step activation function
+func calcStepDerivative(x float64) float64 {
+	return 1
+}
+
 // calcSigmoid calculate sigmoid activation function
 func calcSigmoid(x float64) float64 {
 	return 1 / (1 + math.Pow(math.E, x*-1))
 }
+
+// calcSigmoidDerivative calculate the derivative of the sigmoid activation function
+func calcSigmoidDerivative(x float64) float64 {
+	s := calcSigmoid(x)
+	return s * (1 - s)
+}
+
+// calcReLU calculate ReLU activation function
+func calcReLU(x float64) float64 {
+	if x > 0 {
+		return x
+	}
+	return 0
+}
+
+// calcReLUDerivative calculate the derivative of the ReLU activation function, taken as 0 at x = 0
+func calcReLUDerivative(x float64) float64 {
+	if x > 0 {
+		return 1
+	}
+	return 0
+}
+
+// leakyReLU will return the leaky ReLU activation function and its derivative with the given slope for negative x
+func leakyReLU(slope float64) actFunc {
+	return actFunc{
+		forward: func(x float64) float64 {
+			if x > 0 {
+				return x
+			}
+			return slope * x
+		},
+		derivative: func(x float64) float64 {
+			if x > 0 {
+				return 1
+			}
+			return slope
+		},
+	}
+}
+
+// elu will return the ELU activation function and its derivative with the given scale for negative x
+func elu(alpha float64) actFunc {
+	return actFunc{
+		forward: func(x float64) float64 {
+			if x > 0 {
+				return x
+			}
+			return alpha * math.Expm1(x)
+		},
+		derivative: func(x float64) float64 {
+			if x > 0 {
+				return 1
+			}
+			return alpha * math.Exp(x)
+		},
+	}
+}
+
+// calcTanhDerivative calculate the derivative of the tanh activation function
+func calcTanhDerivative(x float64) float64 {
+	t := math.Tanh(x)
+	return 1 - t*t
+}
+
+// calcSoftplus calculate softplus activation function, log(1 + e^x), without overflowing for large x.
+// Its derivative is the sigmoid.
+func calcSoftplus(x float64) float64 {
+	return math.Max(x, 0) + math.Log1p(math.Exp(-math.Abs(x)))
+}
+
+// calcGELU calculate GELU activation function, x * Φ(x) where Φ is the standard normal CDF
+func calcGELU(x float64) float64 {
+	return x * normalCDF(x)
+}
+
+// calcGELUDerivative calculate the derivative of the GELU activation function
+func calcGELUDerivative(x float64) float64 {
+	return normalCDF(x) + x*math.Exp(-x*x/2)/math.Sqrt(2*math.Pi)
+}
+
+// normalCDF calculate the cumulative distribution function of the standard normal distribution
+func normalCDF(x float64) float64 {
+	return 0.5 * (1 + math.Erf(x/math.Sqrt2))
+}
+
+// calcSwish calculate swish activation function, x * sigmoid(x)
+func calcSwish(x float64) float64 {
+	return x * calcSigmoid(x)
+}
+
+// calcSwishDerivative calculate the derivative of the swish activation function
+func calcSwishDerivative(x float64) float64 {
+	s := calcSigmoid(x)
+	return s + x*s*(1-s)
+}
@@ -1,7 +1,9 @@
 package actfuncs
 
 import (
+	"fmt"
 	"math"
+	"sync"
 	"testing"
 )
 
@@ -83,3 +85,229 @@ func TestApplyActFunc(t *testing.T) {
 	}
 
 }
+
+// numericDerivative will return the central finite difference of the given activation function at x.
+func numericDerivative(actFunc string, x float64) float64 {
+	const h = 1e-6
+	return (ApplyActFunc(actFunc, x+h) - ApplyActFunc(actFunc, x-h)) / (2 * h)
+}
+
+func TestApplyActFuncDerivative(t *testing.T) {
+	xs := []float64{-5, -1, -0.1, 0, 0.1, 1, 5}
+
+	for _, x := range xs {
+		dSigmoid := ApplyActFuncDerivative(Sigmoid, x)
+		if math.Abs(dSigmoid-numericDerivative(Sigmoid, x)) > 1e-8 {
+			t.Error("For Sigmoid at", x, "Expected", numericDerivative(Sigmoid, x), "Got", dSigmoid)
+		}
+
+		dNone := ApplyActFuncDerivative(NoActFunc, x)
+		if dNone != 1 {
+			t.Error("For NoActFunc at", x, "Expected", 1, "Got", dNone)
+		}
+
+		//step uses the straight-through surrogate
+		dStep := ApplyActFuncDerivative(Step, x)
+		if dStep != 1 {
+			t.Error("For Step at", x, "Expected", 1, "Got", dStep)
+		}
+
+		dUnknown := ApplyActFuncDerivative("", x)
+		if dUnknown != 1 {
+			t.Error("For unknown at", x, "Expected", 1, "Got", dUnknown)
+		}
+	}
+
+	if ApplyActFuncDerivative(Sigmoid, 0) != 0.25 {
+		t.Error("For Sigmoid at 0", "Expected", 0.25, "Got", ApplyActFuncDerivative(Sigmoid, 0))
+	}
+}
+
+func TestModernActFuncs(t *testing.T) {
+	actFuncs := []string{ReLU, LeakyReLU, ELU, Tanh, Softplus, GELU, Swish}
+	for _, actFunc := range actFuncs {
+		if !IsValidActFunc(actFunc) {
+			t.Error("For IsValidActFunc", actFunc, "Expected", true, "Got", false)
+		}
+	}
+	if IsValidActFunc("invalid") {
+		t.Error("For IsValidActFunc invalid", "Expected", false, "Got", true)
+	}
+
+	if ApplyActFunc(ReLU, -2) != 0 || ApplyActFunc(ReLU, 2) != 2 {
+		t.Error("For ReLU Expected 0, 2 Got", ApplyActFunc(ReLU, -2), ApplyActFunc(ReLU, 2))
+	}
+	if ApplyActFunc(LeakyReLU, -2) != -2*DefaultLeakyReLUSlope || ApplyActFunc(LeakyReLU, 2) != 2 {
+		t.Error("For LeakyReLU Expected", -2*DefaultLeakyReLUSlope, 2, "Got", ApplyActFunc(LeakyReLU, -2), ApplyActFunc(LeakyReLU, 2))
+	}
+	forward, derivative, _ := LookupAlpha(LeakyReLU, 0.2)
+	if forward(-2) != -0.4 || derivative(-2) != 0.2 || forward(2) != 2 {
+		t.Error("For LeakyReLU with slope 0.2 Expected", -0.4, 0.2, 2, "Got", forward(-2), derivative(-2), forward(2))
+	}
+	forward, derivative, _ = LookupAlpha(ELU, 2)
+	if math.Abs(forward(-1)-2*(math.Exp(-1)-1)) > 1e-12 || math.Abs(derivative(-1)-2*math.Exp(-1)) > 1e-12 {
+		t.Error("For ELU with alpha 2 Expected", 2*(math.Exp(-1)-1), 2*math.Exp(-1), "Got", forward(-1), derivative(-1))
+	}
+	forward, _, _ = LookupAlpha(LeakyReLU, 0)
+	if forward(-2) != -2*DefaultLeakyReLUSlope {
+		t.Error("For LeakyReLU with alpha 0 Expected the default", -2*DefaultLeakyReLUSlope, "Got", forward(-2))
+	}
+	forward, _, _ = LookupAlpha(Tanh, 5)
+	if forward(0.5) != math.Tanh(0.5) || DefaultAlpha(Tanh) != 0 || DefaultAlpha(ELU) != DefaultELUAlpha {
+		t.Error("For Tanh with an alpha Expected it to be ignored", "Got", forward(0.5), DefaultAlpha(Tanh))
+	}
+	if math.Abs(ApplyActFunc(ELU, -1)-(math.Exp(-1)-1)) > 1e-12 {
+		t.Error("For ELU Expected", math.Exp(-1)-1, "Got", ApplyActFunc(ELU, -1))
+	}
+	if ApplyActFunc(Tanh, 0.5) != math.Tanh(0.5) {
+		t.Error("For Tanh Expected", math.Tanh(0.5), "Got", ApplyActFunc(Tanh, 0.5))
+	}
+	if math.Abs(ApplyActFunc(Softplus, 0)-math.Ln2) > 1e-12 || ApplyActFunc(Softplus, 1000) != 1000 {
+		t.Error("For Softplus Expected", math.Ln2, 1000, "Got", ApplyActFunc(Softplus, 0), ApplyActFunc(Softplus, 1000))
+	}
+	if math.Abs(ApplyActFunc(GELU, 1)-0.8413447460685429) > 1e-12 {
+		t.Error("For GELU Expected", 0.8413447460685429, "Got", ApplyActFunc(GELU, 1))
+	}
+	if ApplyActFunc(Swish, 2) != 2*calcSigmoid(2) || ApplyActFunc(SiLU, 2) != 2*calcSigmoid(2) {
+		t.Error("For Swish Expected", 2*calcSigmoid(2), "Got", ApplyActFunc(Swish, 2))
+	}
+
+	//0 is skipped so the kinks in the ReLU family are not sampled
+	xs := []float64{-5, -1, -0.1, 0.1, 1, 5}
+	for _, actFunc := range actFuncs {
+		for _, x := range xs {
+			d := ApplyActFuncDerivative(actFunc, x)
+			if math.Abs(d-numericDerivative(actFunc, x)) > 1e-6 {
+				t.Error("For", actFunc, "derivative at", x, "Expected", numericDerivative(actFunc, x), "Got", d)
+			}
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	cube := func(x float64) float64 { return x * x * x }
+	cubeDerivative := func(x float64) float64 { return 3 * x * x }
+
+	err := Register("testCube", cube, cubeDerivative)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsValidActFunc("testCube") {
+		t.Error("For IsValidActFunc testCube", "Expected", true, "Got", false)
+	}
+	if ApplyActFunc("testCube", 2) != 8 {
+		t.Error("For testCube Expected", 8, "Got", ApplyActFunc("testCube", 2))
+	}
+	if ApplyActFuncDerivative("testCube", 2) != 12 {
+		t.Error("For testCube derivative Expected", 12, "Got", ApplyActFuncDerivative("testCube", 2))
+	}
+
+	err2 := Register("testCube", cube, cubeDerivative)
+	if err2 == nil {
+		t.Error("For duplicate name, did not recieve error")
+	}
+	err3 := Register(Sigmoid, cube, cubeDerivative)
+	if err3 == nil {
+		t.Error("For built in name, did not recieve error")
+	}
+	if ApplyActFunc(Sigmoid, 0) != 0.5 {
+		t.Error("For Sigmoid after failed register Expected", 0.5, "Got", ApplyActFunc(Sigmoid, 0))
+	}
+	err4 := Register("", cube, cubeDerivative)
+	if err4 == nil {
+		t.Error("For empty name, did not recieve error")
+	}
+	err5 := Register("testNil", cube, nil)
+	if err5 == nil {
+		t.Error("For nil derivative, did not recieve error")
+	}
+	if IsValidActFunc("testNil") {
+		t.Error("For IsValidActFunc testNil", "Expected", false, "Got", true)
+	}
+}
+
+func TestRegisterConcurrent(t *testing.T) {
+	const numWorkers = 20
+	var wg sync.WaitGroup
+	errs := make([]error, numWorkers)
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			//every worker races to register the same shared name and its own unique one
+			Register("testShared", calcIdentity, calcIdentityDerivative)
+			errs[i] = Register(fmt.Sprintf("testConcurrent%d", i), calcIdentity, calcIdentityDerivative)
+			ApplyActFunc(Sigmoid, 1)
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < numWorkers; i++ {
+		if errs[i] != nil {
+			t.Error(errs[i])
+		}
+		if !IsValidActFunc(fmt.Sprintf("testConcurrent%d", i)) {
+			t.Error("For IsValidActFunc", fmt.Sprintf("testConcurrent%d", i), "Expected", true, "Got", false)
+		}
+	}
+	if !IsValidActFunc("testShared") {
+		t.Error("For IsValidActFunc testShared", "Expected", true, "Got", false)
+	}
+}
+
+func TestApplySoftmax(t *testing.T) {
+	if !IsValidActFunc(Softmax) {
+		t.Error("For IsValidActFunc Softmax", "Expected", true, "Got", false)
+	}
+	if Register(Softmax, calcIdentity, calcIdentityDerivative) == nil {
+		t.Error("For Register Softmax, did not recieve error")
+	}
+
+	xs := []float64{1, 2, 3}
+	out := make([]float64, 3)
+	ApplySoftmax(xs, out)
+	sum := 0.0
+	for i := range out {
+		sum += out[i]
+	}
+	if math.Abs(sum-1) > 1e-12 {
+		t.Error("For sum of softmax", "Expected", 1, "Got", sum)
+	}
+	if !(out[0] < out[1] && out[1] < out[2]) {
+		t.Error("For softmax order", "Expected increasing", "Got", out)
+	}
+	if math.Abs(out[2]/out[1]-math.E) > 1e-12 {
+		t.Error("For softmax ratio", "Expected", math.E, "Got", out[2]/out[1])
+	}
+
+	//large values must not overflow and the result must not depend on a shift of every x
+	large := []float64{1001, 1002, 1003}
+	ApplySoftmax(large, large)
+	for i := range large {
+		if math.IsNaN(large[i]) || math.Abs(large[i]-out[i]) > 1e-12 {
+			t.Errorf("For softmax of large[%d] Expected %f Got %f", i, out[i], large[i])
+		}
+	}
+
+	//the Jacobian product against finite differences of sum(grads[i] * softmax(xs)[i])
+	grads := []float64{0.3, -1, 2}
+	jac := make([]float64, 3)
+	ApplySoftmaxJacobian(out, grads, jac)
+	const h = 1e-6
+	for i := range xs {
+		weighted := func(delta float64) float64 {
+			shifted := append([]float64{}, xs...)
+			shifted[i] += delta
+			ApplySoftmax(shifted, shifted)
+			total := 0.0
+			for j := range shifted {
+				total += grads[j] * shifted[j]
+			}
+			return total
+		}
+		numeric := (weighted(h) - weighted(-h)) / (2 * h)
+		if math.Abs(numeric-jac[i]) > 1e-8 {
+			t.Errorf("For softmax Jacobian[%d] Expected %f Got %f", i, numeric, jac[i])
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package neuralnet
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jyakimischak/neuralnet/actfuncs"
+	"github.com/jyakimischak/neuralnet/losses"
+	"github.com/jyakimischak/neuralnet/optimizers"
+)
+
+//*************************************************************************************************************
+//neuralLayer
+
+// backward will accumulate the gradients for every neuron in this layer and return the gradient of the loss with
+// respect to the layer's inputs, which is the output gradient of the previous layer.  The layer's Inputs,
+// OutBeforeAct and Outputs must be from the last calc.
+func (nl *neuralLayer) backward(outputGrads []float64) []float64 {
+	if nl.ActFunc == actfuncs.Softmax {
+		actfuncs.ApplySoftmaxJacobian(nl.Outputs, outputGrads, nl.Deltas)
+		return nl.backwardDeltas(nl.Deltas)
+	}
+	_, derivative, ok := actfuncs.LookupAlpha(nl.ActFunc, nl.ActAlpha)
+	for iNeurons := 0; iNeurons < nl.NumNeurons; iNeurons++ {
+		nl.Deltas[iNeurons] = outputGrads[iNeurons]
+		if nl.Dropout > 0 {
+			nl.Deltas[iNeurons] *= nl.DropoutMask[iNeurons]
+		}
+		if ok {
+			nl.Deltas[iNeurons] *= derivative(nl.OutBeforeAct[iNeurons])
+		}
+	}
+	return nl.backwardDeltas(nl.Deltas)
+}
+
+// backwardDeltas will accumulate the gradients for every neuron in this layer given the gradients of the loss with
+// respect to each neuron's OutBeforeAct, and return the gradient of the loss with respect to the layer's inputs.
+func (nl *neuralLayer) backwardDeltas(deltas []float64) []float64 {
+	copy(nl.Deltas, deltas)
+	if nl.Kind != LayerKindDense {
+		inputGrads := make([]float64, nl.NumInputs)
+		nl.backwardNorm(deltas, 1, nl.XHat, nl.InvStd, false, inputGrads)
+		return inputGrads
+	}
+	for iNeurons := 0; iNeurons < nl.NumNeurons; iNeurons++ {
+		axpy(deltas[iNeurons], nl.Inputs, nl.WeightGrads[iNeurons*nl.NumInputs:(iNeurons+1)*nl.NumInputs])
+		nl.BiasGrads[iNeurons] += deltas[iNeurons]
+	}
+	inputGrads := make([]float64, nl.NumInputs)
+	matTransVecAdd(nl.Weights, nl.NumNeurons, nl.NumInputs, deltas, inputGrads)
+	return inputGrads
+}
+
+// fusedLossDeltas will set deltas to the gradient of the loss with respect to OutBeforeAct for one row of outputs
+// and targets when the loss function and this layer's activation function combine into a simpler gradient, and
+// return false otherwise.  Softmax with categorical cross-entropy gives outputs - targets and Sigmoid with binary
+// cross-entropy gives the same averaged over the outputs, both stay accurate even when an output is close to 0 or 1.
+func (nl *neuralLayer) fusedLossDeltas(lossFunc string, outputs []float64, targets []float64, deltas []float64) bool {
+	switch {
+	case nl.ActFunc == actfuncs.Softmax && lossFunc == losses.CategoricalCrossEntropy:
+		for i := range deltas {
+			deltas[i] = outputs[i] - targets[i]
+		}
+		return true
+	case nl.ActFunc == actfuncs.Sigmoid && lossFunc == losses.BinaryCrossEntropy:
+		for i := range deltas {
+			deltas[i] = (outputs[i] - targets[i]) / float64(len(deltas))
+		}
+		return true
+	}
+	return false
+}
+
+// zeroGrads will reset the accumulated gradients for this layer.
+func (nl *neuralLayer) zeroGrads() {
+	for i := range nl.WeightGrads {
+		nl.WeightGrads[i] = 0
+	}
+	for i := range nl.BiasGrads {
+		nl.BiasGrads[i] = 0
+	}
+	for i := range nl.GammaGrads {
+		nl.GammaGrads[i] = 0
+		nl.BetaGrads[i] = 0
+	}
+}
+
+// updateWeights will move the weights and biases, or Gamma and Beta, against their accumulated gradients.
+func (nl *neuralLayer) updateWeights(learningRate float64) {
+	axpy(-learningRate, nl.WeightGrads, nl.Weights)
+	axpy(-learningRate, nl.BiasGrads, nl.Biases)
+	axpy(-learningRate, nl.GammaGrads, nl.Gamma)
+	axpy(-learningRate, nl.BetaGrads, nl.Beta)
+}
+
+//*************************************************************************************************************
+//NeuralNetwork
+
+// Backward will propagate the gradient of the loss with respect to the outputs back through every layer, starting at
+// the output layer, and accumulate the weight and bias gradients on each layer.  Calc must have been called first
+// so that the inputs and OutBeforeAct of every layer are from the same forward pass.  Gradients are added to any
+// already accumulated, call ZeroGrads to reset them.  A BatchNorm layer is differentiated with its running statistics
+// held constant, as Calc normalized with them.
+func (nn *NeuralNetwork) Backward(outputGrads []float64) error {
+	isValid, invalidMsg := nn.IsValid()
+	if !isValid {
+		return errors.New(invalidMsg)
+	}
+	if len(outputGrads) != nn.OutputLayer.NumNeurons {
+		return fmt.Errorf("len(outputGrads) must be %d and is: %d", nn.OutputLayer.NumNeurons, len(outputGrads))
+	}
+	return nn.backwardRecurse(1, nn.OutputLayer, nn.OutputLayer.backward(outputGrads))
+}
+
+// BackwardCrossEntropy will propagate the gradient of the categorical cross-entropy between the outputs and the
+// targets, -sum(targets[i] * log(outputs[i])), back through every layer like Backward.  When the output layer uses
+// Softmax the softmax Jacobian and the cross-entropy gradient are combined into outputs - targets, which stays
+// accurate even when an output is close to 0.
+func (nn *NeuralNetwork) BackwardCrossEntropy(targets []float64) error {
+	return nn.BackwardLoss(losses.CategoricalCrossEntropy, targets)
+}
+
+// BackwardLoss will propagate the gradient of the given loss function between the outputs of the last Calc and the
+// targets back through every layer like Backward.  Softmax with categorical cross-entropy and Sigmoid with binary
+// cross-entropy are combined into a single gradient with respect to OutBeforeAct, see fusedLossDeltas.
+func (nn *NeuralNetwork) BackwardLoss(lossFunc string, targets []float64) error {
+	isValid, invalidMsg := nn.IsValid()
+	if !isValid {
+		return errors.New(invalidMsg)
+	}
+	if len(targets) != nn.OutputLayer.NumNeurons {
+		return fmt.Errorf("len(targets) must be %d and is: %d", nn.OutputLayer.NumNeurons, len(targets))
+	}
+
+	ol := nn.OutputLayer
+	deltas := make([]float64, ol.NumNeurons)
+	if ol.fusedLossDeltas(lossFunc, ol.Outputs, targets, deltas) {
+		return nn.backwardRecurse(1, ol, ol.backwardDeltas(deltas))
+	}
+
+	outputGrads, err := losses.CalcGradient(lossFunc, ol.Outputs, targets)
+	if err != nil {
+		return err
+	}
+	return nn.backwardRecurse(1, ol, ol.backward(outputGrads))
+}
+
+// Loss will return the value of the given loss function between the outputs of the last Calc and the targets.
+func (nn *NeuralNetwork) Loss(lossFunc string, targets []float64) (float64, error) {
+	if nn.OutputLayer == nil {
+		return 0, errors.New("Invalid neural network. Did you call NewNeuralNetwork when getting the instance?")
+	}
+	return losses.CalcLoss(lossFunc, nn.OutputLayer.Outputs, targets)
+}
+
+// backwardRecurse will continue backpropagation from layer, whose gradients are already accumulated, given the
+// gradient of the loss with respect to its inputs.
+func (nn *NeuralNetwork) backwardRecurse(depth int, layer *neuralLayer, inputGrads []float64) error {
+	if depth == maxRecurseDepth {
+		return errors.New("Max recurse depth reached")
+	}
+	//if we hit the input layer we are done
+	if layer.LayerType == layerTypeInput {
+		return nil
+	}
+	prevLayer := layer.PrevLayer
+	return nn.backwardRecurse(depth+1, prevLayer, prevLayer.backward(inputGrads))
+}
+
+// ZeroGrads will reset the accumulated gradients of every layer in the network.
+func (nn *NeuralNetwork) ZeroGrads() {
+	for layer := nn.InputLayer; layer != nil; layer = layer.NextLayer {
+		layer.zeroGrads()
+	}
+}
+
+// UpdateWeights will apply one step of gradient descent to every weight and bias in the network using the
+// accumulated gradients.
+func (nn *NeuralNetwork) UpdateWeights(learningRate float64) {
+	for layer := nn.InputLayer; layer != nil; layer = layer.NextLayer {
+		layer.updateWeights(learningRate)
+	}
+}
+
+// Step will update every weight and bias in the network from the accumulated gradients using the given optimizer.
+func (nn *NeuralNetwork) Step(opt optimizers.Optimizer) error {
+	isValid, invalidMsg := nn.IsValid()
+	if !isValid {
+		return errors.New(invalidMsg)
+	}
+	return opt.Step(nn.Params())
+}
+
+// Train will run a single sample through the network and apply one step of gradient descent that reduces half of
+// the sum of squared errors between the outputs and the targets.  The loss before the update is returned.  A network
+// with a BatchNorm layer returns an error since a single sample has no batch statistics, train it with Fit.
+func (nn *NeuralNetwork) Train(inputs []float64, targets []float64, learningRate float64) (float64, error) {
+	err := nn.checkInputs(inputs)
+	if err != nil {
+		return 0, err
+	}
+	for _, layer := range nn.HiddenLayers {
+		if layer.Kind == LayerKindBatchNorm {
+			return 0, fmt.Errorf("Train can not train a network with a %s layer, use Fit", LayerKindBatchNorm)
+		}
+	}
+	if len(targets) != nn.OutputLayer.NumNeurons {
+		return 0, fmt.Errorf("len(targets) must be %d and is: %d", nn.OutputLayer.NumNeurons, len(targets))
+	}
+
+	copy(nn.InputLayer.Inputs, inputs)
+	err = nn.Calc()
+	if err != nil {
+		return 0, err
+	}
+
+	loss := 0.0
+	outputGrads := make([]float64, len(targets))
+	for i := 0; i < len(targets); i++ {
+		diff := nn.OutputLayer.Outputs[i] - targets[i]
+		loss += 0.5 * diff * diff
+		outputGrads[i] = diff
+	}
+
+	nn.ZeroGrads()
+	err = nn.Backward(outputGrads)
+	if err != nil {
+		return 0, err
+	}
+	nn.UpdateWeights(learningRate)
+
+	return loss, nil
+}
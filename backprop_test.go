@@ -0,0 +1,377 @@
+package neuralnet
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jyakimischak/neuralnet/actfuncs"
+	"github.com/jyakimischak/neuralnet/losses"
+	"github.com/jyakimischak/neuralnet/optimizers"
+)
+
+func TestNeuralLayerBackward(t *testing.T) {
+	nl, err := getKnownStateNeuralLayer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = nl.calc()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inputGrads := nl.backward([]float64{2, 0, -1})
+	if nl.Deltas[0] != 2 || nl.Deltas[1] != 0 || nl.Deltas[2] != -1 {
+		t.Error("For nl.Deltas", "Expected", []float64{2, 0, -1}, "Got", nl.Deltas)
+	}
+	for i := 0; i < 3; i++ {
+		if nl.WeightGrads[i] != 2*nl.Inputs[i] {
+			t.Errorf("For nl.WeightGrads[%d] Expected %f Got %f", i, 2*nl.Inputs[i], nl.WeightGrads[i])
+		}
+		if nl.WeightGrads[6+i] != -nl.Inputs[i] {
+			t.Errorf("For nl.WeightGrads[%d] Expected %f Got %f", 6+i, -nl.Inputs[i], nl.WeightGrads[6+i])
+		}
+	}
+	if nl.BiasGrads[0] != 2 {
+		t.Error("For nl.BiasGrads[0]", "Expected", 2, "Got", nl.BiasGrads[0])
+	}
+	//every neuron has the weights 10, 20, 30 so the input gradients are (2 + 0 - 1) * weight
+	if inputGrads[0] != 10 || inputGrads[1] != 20 || inputGrads[2] != 30 {
+		t.Error("For inputGrads", "Expected", []float64{10, 20, 30}, "Got", inputGrads)
+	}
+
+	//gradients accumulate until they are zeroed
+	nl.backward([]float64{2, 0, -1})
+	if nl.BiasGrads[0] != 4 {
+		t.Error("For accumulated nl.BiasGrads[0]", "Expected", 4, "Got", nl.BiasGrads[0])
+	}
+	nl.zeroGrads()
+	if nl.BiasGrads[0] != 0 || nl.WeightGrads[0] != 0 {
+		t.Error("For zeroGrads", "Expected", 0, "Got", nl.BiasGrads[0], nl.WeightGrads[0])
+	}
+}
+
+// halfSquaredError will run the inputs through the network and return half of the sum of squared errors.
+func halfSquaredError(nn *NeuralNetwork, inputs []float64, targets []float64) float64 {
+	copy(nn.InputLayer.Inputs, inputs)
+	nn.Calc()
+	loss := 0.0
+	for i := 0; i < len(targets); i++ {
+		diff := nn.OutputLayer.Outputs[i] - targets[i]
+		loss += 0.5 * diff * diff
+	}
+	return loss
+}
+
+func TestNeuralNetworkBackward(t *testing.T) {
+	nn, err := NewNeuralNetwork(
+		InputLayerProps{NumInputs: 2},
+		[]HiddenLayerProps{
+			HiddenLayerProps{NumNeurons: 3, ActFunc: actfuncs.Sigmoid},
+		},
+		OutputLayerProps{NumOutputs: 2, ActFunc: actfuncs.Sigmoid},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = nn.Backward([]float64{1})
+	if err == nil {
+		t.Error("For wrong number of output gradients, did not recieve error")
+	}
+
+	inputs := []float64{0.3, -0.7}
+	targets := []float64{0.1, 0.9}
+	halfSquaredError(nn, inputs, targets)
+	outputGrads := make([]float64, 2)
+	for i := 0; i < 2; i++ {
+		outputGrads[i] = nn.OutputLayer.Outputs[i] - targets[i]
+	}
+	nn.ZeroGrads()
+	err = nn.Backward(outputGrads)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkGradients(t, nn, func() float64 { return halfSquaredError(nn, inputs, targets) })
+}
+
+// checkGradients will compare every accumulated weight and bias gradient in the network with a central finite
+// difference of the given loss function.
+func checkGradients(t *testing.T, nn *NeuralNetwork, lossFn func() float64) {
+	for layer := nn.InputLayer; layer != nil; layer = layer.NextLayer {
+		checkParamGradients(t, layer.LayerType+" weight", layer.Weights, layer.WeightGrads, lossFn)
+		checkParamGradients(t, layer.LayerType+" bias", layer.Biases, layer.BiasGrads, lossFn)
+		checkParamGradients(t, layer.Kind+" gamma", layer.Gamma, layer.GammaGrads, lossFn)
+		checkParamGradients(t, layer.Kind+" beta", layer.Beta, layer.BetaGrads, lossFn)
+	}
+}
+
+// checkParamGradients will compare the gradients of the given parameters with a central finite difference of the
+// given loss function.
+func checkParamGradients(t *testing.T, name string, params []float64, grads []float64, lossFn func() float64) {
+	const h = 1e-6
+	for i := range params {
+		orig := params[i]
+		params[i] = orig + h
+		lossPlus := lossFn()
+		params[i] = orig - h
+		lossMinus := lossFn()
+		params[i] = orig
+		numeric := (lossPlus - lossMinus) / (2 * h)
+		if math.Abs(numeric-grads[i]) > 1e-6 {
+			t.Errorf("For %s gradient [%d] Expected %g Got %g", name, i, numeric, grads[i])
+		}
+	}
+}
+
+func TestNeuralNetworkTrain(t *testing.T) {
+	nn, err := NewNeuralNetwork(
+		InputLayerProps{NumInputs: 2},
+		[]HiddenLayerProps{
+			HiddenLayerProps{NumNeurons: 4, ActFunc: actfuncs.Sigmoid},
+		},
+		OutputLayerProps{NumOutputs: 1, ActFunc: actfuncs.NoActFunc},
+		WithSeed(1),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = nn.Train([]float64{1}, []float64{1}, 0.1)
+	if err == nil {
+		t.Error("For wrong number of inputs, did not recieve error")
+	}
+	_, err = nn.Train([]float64{1, 2}, []float64{1, 2}, 0.1)
+	if err == nil {
+		t.Error("For wrong number of targets, did not recieve error")
+	}
+
+	//learn y = x0 - x1
+	samples := [][]float64{{0, 0}, {0, 1}, {1, 0}, {1, 1}, {0.5, 0.25}}
+	firstLoss := 0.0
+	lastLoss := 0.0
+	for epoch := 0; epoch < 500; epoch++ {
+		epochLoss := 0.0
+		for _, s := range samples {
+			loss, err := nn.Train(s, []float64{s[0] - s[1]}, 0.05)
+			if err != nil {
+				t.Fatal(err)
+			}
+			epochLoss += loss
+		}
+		if epoch == 0 {
+			firstLoss = epochLoss
+		}
+		lastLoss = epochLoss
+	}
+	if lastLoss >= firstLoss || lastLoss > 0.01 {
+		t.Error("For training loss", "Expected it to drop below", 0.01, "from", firstLoss, "Got", lastLoss)
+	}
+}
+
+// crossEntropy will run the inputs through the network and return the categorical cross-entropy.
+func crossEntropy(nn *NeuralNetwork, inputs []float64, targets []float64) float64 {
+	copy(nn.InputLayer.Inputs, inputs)
+	nn.Calc()
+	loss := 0.0
+	for i := 0; i < len(targets); i++ {
+		loss -= targets[i] * math.Log(nn.OutputLayer.Outputs[i])
+	}
+	return loss
+}
+
+func TestNeuralNetworkSoftmax(t *testing.T) {
+	nn, err := NewNeuralNetwork(
+		InputLayerProps{NumInputs: 2},
+		[]HiddenLayerProps{
+			HiddenLayerProps{NumNeurons: 4, ActFunc: actfuncs.Tanh},
+		},
+		OutputLayerProps{NumOutputs: 3, ActFunc: actfuncs.Softmax},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inputs := []float64{0.4, -0.2}
+	targets := []float64{0, 1, 0}
+	crossEntropy(nn, inputs, targets)
+	sum := 0.0
+	for _, o := range nn.OutputLayer.Outputs {
+		if o <= 0 || o >= 1 {
+			t.Error("For softmax output", "Expected value in (0, 1)", "Got", o)
+		}
+		sum += o
+	}
+	if math.Abs(sum-1) > 1e-12 {
+		t.Error("For sum of softmax outputs", "Expected", 1, "Got", sum)
+	}
+
+	//the combined softmax and cross-entropy gradient
+	nn.ZeroGrads()
+	err = nn.BackwardCrossEntropy(targets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGradients(t, nn, func() float64 { return crossEntropy(nn, inputs, targets) })
+
+	//the softmax Jacobian on its own, for an arbitrary loss
+	weights := []float64{0.5, -1, 2}
+	weightedSum := func() float64 {
+		copy(nn.InputLayer.Inputs, inputs)
+		nn.Calc()
+		loss := 0.0
+		for i := range weights {
+			loss += weights[i] * nn.OutputLayer.Outputs[i]
+		}
+		return loss
+	}
+	weightedSum()
+	nn.ZeroGrads()
+	err = nn.Backward(weights)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGradients(t, nn, weightedSum)
+
+	err = nn.BackwardCrossEntropy([]float64{1})
+	if err == nil {
+		t.Error("For wrong number of targets, did not recieve error")
+	}
+}
+
+func TestNeuralNetworkSoftmaxTrain(t *testing.T) {
+	nn, err := NewNeuralNetwork(
+		InputLayerProps{NumInputs: 2},
+		[]HiddenLayerProps{
+			HiddenLayerProps{NumNeurons: 6, ActFunc: actfuncs.Tanh},
+		},
+		OutputLayerProps{NumOutputs: 3, ActFunc: actfuncs.Softmax},
+		WithSeed(1),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	samples := [][]float64{{1, 0}, {0, 1}, {-1, -1}}
+	targets := [][]float64{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+	totalLoss := func() float64 {
+		loss := 0.0
+		for i := range samples {
+			loss += crossEntropy(nn, samples[i], targets[i])
+		}
+		return loss
+	}
+
+	firstLoss := totalLoss()
+	for epoch := 0; epoch < 300; epoch++ {
+		for i := range samples {
+			crossEntropy(nn, samples[i], targets[i])
+			nn.ZeroGrads()
+			err = nn.BackwardCrossEntropy(targets[i])
+			if err != nil {
+				t.Fatal(err)
+			}
+			nn.UpdateWeights(0.1)
+		}
+	}
+	lastLoss := totalLoss()
+	if lastLoss >= firstLoss || lastLoss > 0.1 {
+		t.Error("For cross-entropy loss", "Expected it to drop below", 0.1, "from", firstLoss, "Got", lastLoss)
+	}
+}
+
+func TestNeuralNetworkBackwardLoss(t *testing.T) {
+	cases := []struct {
+		actFunc  string
+		lossFunc string
+		targets  []float64
+	}{
+		{actfuncs.NoActFunc, losses.MSE, []float64{0.5, -0.25}},
+		{actfuncs.NoActFunc, losses.MAE, []float64{0.5, -0.25}},
+		{actfuncs.NoActFunc, losses.Huber, []float64{3, -0.25}},
+		{actfuncs.Sigmoid, losses.BinaryCrossEntropy, []float64{1, 0}},
+		{actfuncs.Softmax, losses.CategoricalCrossEntropy, []float64{0, 1}},
+		{actfuncs.Tanh, losses.Hinge, []float64{1, -1}},
+	}
+	inputs := []float64{0.4, -0.2}
+	for _, c := range cases {
+		nn, err := NewNeuralNetwork(
+			InputLayerProps{NumInputs: 2},
+			[]HiddenLayerProps{
+				HiddenLayerProps{NumNeurons: 3, ActFunc: actfuncs.Tanh},
+			},
+			OutputLayerProps{NumOutputs: 2, ActFunc: c.actFunc},
+			WithSeed(1),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lossFn := func() float64 {
+			copy(nn.InputLayer.Inputs, inputs)
+			nn.Calc()
+			loss, _ := nn.Loss(c.lossFunc, c.targets)
+			return loss
+		}
+
+		lossFn()
+		nn.ZeroGrads()
+		err = nn.BackwardLoss(c.lossFunc, c.targets)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkGradients(t, nn, lossFn)
+	}
+
+	nn, _ := NewNeuralNetwork(InputLayerProps{NumInputs: 1}, nil, OutputLayerProps{NumOutputs: 1, ActFunc: actfuncs.NoActFunc})
+	err := nn.BackwardLoss("invalid", []float64{1})
+	if err == nil {
+		t.Error("For unknown loss function, did not recieve error")
+	}
+	_, err = nn.Loss(losses.MSE, []float64{1, 2})
+	if err == nil {
+		t.Error("For wrong number of targets, did not recieve error")
+	}
+}
+
+func TestNeuralNetworkStep(t *testing.T) {
+	samples := [][]float64{{1, 0}, {0, 1}, {-1, -1}}
+	targets := [][]float64{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+
+	for _, opt := range []optimizers.Optimizer{optimizers.NewMomentum(0.05), optimizers.NewAdam(0.01)} {
+		nn, err := NewNeuralNetwork(
+			InputLayerProps{NumInputs: 2},
+			[]HiddenLayerProps{
+				HiddenLayerProps{NumNeurons: 6, ActFunc: actfuncs.Tanh},
+			},
+			OutputLayerProps{NumOutputs: 3, ActFunc: actfuncs.Softmax},
+			WithSeed(1),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		totalLoss := func() float64 {
+			loss := 0.0
+			for i := range samples {
+				loss += crossEntropy(nn, samples[i], targets[i])
+			}
+			return loss
+		}
+
+		firstLoss := totalLoss()
+		for epoch := 0; epoch < 300; epoch++ {
+			nn.ZeroGrads()
+			for i := range samples {
+				crossEntropy(nn, samples[i], targets[i])
+				nn.BackwardCrossEntropy(targets[i])
+			}
+			err = nn.Step(opt)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		lastLoss := totalLoss()
+		if lastLoss >= firstLoss || lastLoss > 0.1 {
+			t.Errorf("For cross-entropy loss with %T Expected it to drop below 0.1 from %f Got %f", opt, firstLoss, lastLoss)
+		}
+	}
+}
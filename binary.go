@@ -0,0 +1,305 @@
+package neuralnet
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"math"
+)
+
+//PrecisionFloat64 SaveBinary writes every parameter as a float64, the network is read back exactly
+const PrecisionFloat64 = "float64"
+
+//PrecisionFloat32 SaveBinary writes every parameter as a float32, half the size but rounded to float32
+const PrecisionFloat32 = "float32"
+
+// binaryMagic starts every file written by SaveBinary.
+var binaryMagic = [4]byte{'N', 'N', 'E', 'T'}
+
+// binaryVersion is the version of the binary format, it is raised whenever the format changes.
+const binaryVersion = 1
+
+// binaryMaxString is the longest layer kind or activation function name LoadBinary reads.
+const binaryMaxString = 1024
+
+// binaryChunk is the most parameters LoadBinary allocates for before reading them, so a corrupted count fails as a
+// truncated file instead of allocating all of it.
+const binaryChunk = 1 << 16
+
+// ErrBadMagic is returned by LoadBinary when the data was not written by SaveBinary.
+var ErrBadMagic = errors.New("not a binary neural network")
+
+// ErrUnsupportedVersion is returned by LoadBinary when the data was written by a newer version of SaveBinary.
+var ErrUnsupportedVersion = errors.New("unsupported binary neural network version")
+
+// ErrTruncated is returned by LoadBinary when the data ends early.
+var ErrTruncated = errors.New("truncated binary neural network")
+
+// ErrChecksum is returned by LoadBinary when the checksum does not match the data.
+var ErrChecksum = errors.New("binary neural network checksum mismatch")
+
+// ErrCorrupt is returned by LoadBinary when the architecture in the data is impossible.
+var ErrCorrupt = errors.New("corrupt binary neural network")
+
+// SaveBinary will write the network to w in a compact binary format that LoadBinary reads back.  It saves the same
+// architecture and parameters as Save, with the parameters written as little-endian float64 or float32 values
+// depending on the precision, PrecisionFloat64 or PrecisionFloat32.
+//
+// The format is the magic "NNET", the format version as a uint16, the number of bytes per parameter as a uint8 and the
+// number of layers as a uint32.  Each layer follows with its kind and activation function, each a uint16 length and
+// the bytes of the name, its NumNeurons and NumInputs as uint32s, ActAlpha, L1, L2 and Dropout as float64s and a
+// uint8 that is 1 if RegularizeBiases is true.  Then come the parameter blocks of every layer, a dense layer's
+// Weights and Biases, or a normalization layer's Gamma and Beta followed by RunningMean and RunningVar for BatchNorm.
+// Last is the CRC-32 (IEEE) of everything before it as a uint32.  Every number is little-endian.
+func (nn *NeuralNetwork) SaveBinary(w io.Writer, precision string) error {
+	var floatSize uint8
+	switch precision {
+	case PrecisionFloat64:
+		floatSize = 8
+	case PrecisionFloat32:
+		floatSize = 4
+	default:
+		return fmt.Errorf("Unknown precision: %s", precision)
+	}
+	saved, err := nn.saved()
+	if err != nil {
+		return err
+	}
+
+	bw := &binaryWriter{w: w, crc: crc32.NewIEEE()}
+	bw.write(binaryMagic)
+	bw.write(uint16(binaryVersion))
+	bw.write(floatSize)
+	bw.write(uint32(len(saved.Layers)))
+	for _, sl := range saved.Layers {
+		bw.writeString(sl.Kind)
+		bw.writeString(sl.ActFunc)
+		bw.write(uint32(sl.NumNeurons))
+		bw.write(uint32(sl.NumInputs))
+		bw.write(sl.ActAlpha)
+		bw.write(sl.L1)
+		bw.write(sl.L2)
+		bw.write(sl.Dropout)
+		regularizeBiases := uint8(0)
+		if sl.RegularizeBiases {
+			regularizeBiases = 1
+		}
+		bw.write(regularizeBiases)
+	}
+	for _, sl := range saved.Layers {
+		for _, block := range sl.blocks() {
+			bw.writeFloats(*block, floatSize)
+		}
+	}
+	if bw.err != nil {
+		return bw.err
+	}
+	//the checksum is not part of itself
+	return binary.Write(w, binary.LittleEndian, bw.crc.Sum32())
+}
+
+// LoadBinary will read a network written by SaveBinary.  The options are applied to the network as they are by
+// NewNeuralNetwork.  Data that is not in the format fails with an error that wraps ErrBadMagic,
+// ErrUnsupportedVersion, ErrTruncated, ErrChecksum or ErrCorrupt, check for them with errors.Is.  As with Load an
+// error is also returned if an activation function the network uses is not registered or the network is not valid.
+func LoadBinary(r io.Reader, options ...Option) (*NeuralNetwork, error) {
+	br := &binaryReader{r: r, crc: crc32.NewIEEE()}
+	var magic [4]byte
+	br.read(&magic)
+	if br.err == nil && magic != binaryMagic {
+		return nil, fmt.Errorf("%w: magic is %q", ErrBadMagic, magic[:])
+	}
+	var version uint16
+	br.read(&version)
+	if br.err == nil && version != binaryVersion {
+		return nil, fmt.Errorf("%w: version must be %d and is: %d", ErrUnsupportedVersion, binaryVersion, version)
+	}
+	var floatSize uint8
+	br.read(&floatSize)
+	if br.err == nil && floatSize != 8 && floatSize != 4 {
+		return nil, fmt.Errorf("%w: bytes per parameter must be 8 or 4 and is: %d", ErrCorrupt, floatSize)
+	}
+	var numLayers uint32
+	br.read(&numLayers)
+	if br.err == nil && (numLayers < 2 || numLayers > maxRecurseDepth) {
+		return nil, fmt.Errorf("%w: number of layers must be from 2 to %d and is: %d", ErrCorrupt, maxRecurseDepth, numLayers)
+	}
+	if br.err != nil {
+		return nil, br.err
+	}
+
+	saved := savedNetwork{Version: saveVersion, Layers: make([]savedLayer, numLayers)}
+	for i := range saved.Layers {
+		sl := &saved.Layers[i]
+		sl.Kind = br.readString()
+		sl.ActFunc = br.readString()
+		var numNeurons, numInputs uint32
+		br.read(&numNeurons)
+		br.read(&numInputs)
+		sl.NumNeurons, sl.NumInputs = int(numNeurons), int(numInputs)
+		br.read(&sl.ActAlpha)
+		br.read(&sl.L1)
+		br.read(&sl.L2)
+		br.read(&sl.Dropout)
+		var regularizeBiases uint8
+		br.read(&regularizeBiases)
+		sl.RegularizeBiases = regularizeBiases == 1
+		if br.err != nil {
+			return nil, br.err
+		}
+		if !isValidLayerKind(sl.Kind) || sl.Kind == "" {
+			return nil, fmt.Errorf("%w: Layers[%d].Kind is unknown: %s", ErrCorrupt, i, sl.Kind)
+		}
+		if numNeurons == 0 || numInputs == 0 || uint64(numNeurons)*uint64(numInputs) > maxLayerParams {
+			return nil, fmt.Errorf("%w: Layers[%d] can not have %d neurons and %d inputs", ErrCorrupt, i, numNeurons, numInputs)
+		}
+	}
+	for i := range saved.Layers {
+		sl := &saved.Layers[i]
+		for _, block := range sl.blocks() {
+			*block = br.readFloats(sl.blockLen(block), floatSize)
+		}
+	}
+	if br.err != nil {
+		return nil, br.err
+	}
+
+	sum := br.crc.Sum32()
+	var checksum uint32
+	err := binary.Read(r, binary.LittleEndian, &checksum)
+	if err != nil {
+		return nil, truncated(err)
+	}
+	if checksum != sum {
+		return nil, fmt.Errorf("%w: checksum is %08x and the data sums to %08x", ErrChecksum, checksum, sum)
+	}
+	return newSavedNeuralNetwork(saved, options...)
+}
+
+// blocks will return the parameter blocks of the layer in the order they are written, pointers so LoadBinary can fill
+// them.
+func (sl *savedLayer) blocks() []*[]float64 {
+	switch sl.Kind {
+	case LayerKindBatchNorm:
+		return []*[]float64{&sl.Gamma, &sl.Beta, &sl.RunningMean, &sl.RunningVar}
+	case LayerKindLayerNorm:
+		return []*[]float64{&sl.Gamma, &sl.Beta}
+	default:
+		return []*[]float64{&sl.Weights, &sl.Biases}
+	}
+}
+
+// blockLen will return the number of parameters in one of the layer's blocks.
+func (sl *savedLayer) blockLen(block *[]float64) int {
+	if block == &sl.Weights {
+		return sl.NumNeurons * sl.NumInputs
+	}
+	return sl.NumNeurons
+}
+
+// truncated will return ErrTruncated for the errors the end of the data gives, and err otherwise.
+func truncated(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return ErrTruncated
+	}
+	return err
+}
+
+//*************************************************************************************************************
+//binaryWriter
+
+// binaryWriter writes little-endian values and adds them to a checksum, keeping the first error so the values can be
+// written one after the other and the error checked once.
+type binaryWriter struct {
+	w   io.Writer
+	crc hash.Hash32
+	err error
+}
+
+// write will write a fixed size value.
+func (bw *binaryWriter) write(value interface{}) {
+	if bw.err != nil {
+		return
+	}
+	bw.err = binary.Write(io.MultiWriter(bw.w, bw.crc), binary.LittleEndian, value)
+}
+
+// writeString will write the length of s as a uint16 and then its bytes.
+func (bw *binaryWriter) writeString(s string) {
+	if len(s) > binaryMaxString && bw.err == nil {
+		bw.err = fmt.Errorf("Names can be at most %d bytes and %q is: %d", binaryMaxString, s, len(s))
+	}
+	bw.write(uint16(len(s)))
+	bw.write([]byte(s))
+}
+
+// writeFloats will write the values as float64s or float32s.
+func (bw *binaryWriter) writeFloats(values []float64, floatSize uint8) {
+	if floatSize == 8 {
+		bw.write(values)
+		return
+	}
+	values32 := make([]float32, len(values))
+	for i, v := range values {
+		values32[i] = float32(v)
+	}
+	bw.write(values32)
+}
+
+//*************************************************************************************************************
+//binaryReader
+
+// binaryReader reads what binaryWriter writes and adds it to a checksum, keeping the first error.  The end of the data
+// is reported as ErrTruncated.
+type binaryReader struct {
+	r   io.Reader
+	crc hash.Hash32
+	err error
+}
+
+// read will read a fixed size value.
+func (br *binaryReader) read(value interface{}) {
+	if br.err != nil {
+		return
+	}
+	br.err = truncated(binary.Read(io.TeeReader(br.r, br.crc), binary.LittleEndian, value))
+}
+
+// readString will read a string written by writeString.
+func (br *binaryReader) readString() string {
+	var length uint16
+	br.read(&length)
+	if br.err != nil {
+		return ""
+	}
+	if length > binaryMaxString {
+		br.err = fmt.Errorf("%w: name is %d bytes, at most %d are allowed", ErrCorrupt, length, binaryMaxString)
+		return ""
+	}
+	b := make([]byte, length)
+	br.read(b)
+	return string(b)
+}
+
+// readFloats will read n float64s or float32s.  They are read in chunks so a corrupted n is not allocated at once.
+func (br *binaryReader) readFloats(n int, floatSize uint8) []float64 {
+	values := make([]float64, 0, int(math.Min(float64(n), binaryChunk)))
+	for len(values) < n && br.err == nil {
+		chunk := int(math.Min(float64(n-len(values)), binaryChunk))
+		if floatSize == 8 {
+			values64 := make([]float64, chunk)
+			br.read(values64)
+			values = append(values, values64...)
+			continue
+		}
+		values32 := make([]float32, chunk)
+		br.read(values32)
+		for _, v := range values32 {
+			values = append(values, float64(v))
+		}
+	}
+	return values
+}
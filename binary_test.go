@@ -0,0 +1,119 @@
+package neuralnet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+func TestNeuralNetworkSaveLoadBinary(t *testing.T) {
+	nn := newSaveTestNeuralNetwork(t)
+	var jsonBuf, buf64, buf32 bytes.Buffer
+	nn.Save(&jsonBuf)
+	err := nn.SaveBinary(&buf64, PrecisionFloat64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = nn.SaveBinary(&buf32, PrecisionFloat32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf64.Len() >= jsonBuf.Len() || buf32.Len() >= buf64.Len() {
+		t.Error("For the size of JSON, float64 and float32", "Expected each to be smaller", "Got", jsonBuf.Len(), buf64.Len(), buf32.Len())
+	}
+
+	//float64 is read back exactly
+	loaded, err := LoadBinary(bytes.NewReader(buf64.Bytes()), WithSeed(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSameNeuralNetwork(t, nn, loaded)
+	inputs := [][]float64{{0.4, -0.2}, {1.5, 0.1}}
+	expected, _ := nn.PredictBatch(inputs)
+	got, _ := loaded.PredictBatch(inputs)
+	for i := range expected {
+		for j := range expected[i] {
+			if got[i][j] != expected[i][j] {
+				t.Error("For the outputs of the loaded network", "Expected", expected[i], "Got", got[i])
+			}
+		}
+	}
+
+	//float32 is read back rounded to float32
+	loaded, err = LoadBinary(bytes.NewReader(buf32.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for iParam, p := range nn.Params() {
+		loadedValues := loaded.Params()[iParam].Values
+		for i := range p.Values {
+			if loadedValues[i] != float64(float32(p.Values[i])) {
+				t.Error("For", p.Name, i, "Expected", float64(float32(p.Values[i])), "Got", loadedValues[i])
+			}
+		}
+	}
+	if loaded.HiddenLayers[1].RunningVar[0] != float64(float32(nn.HiddenLayers[1].RunningVar[0])) {
+		t.Error("For RunningVar[0]", "Expected", float64(float32(nn.HiddenLayers[1].RunningVar[0])), "Got", loaded.HiddenLayers[1].RunningVar[0])
+	}
+
+	err = nn.SaveBinary(&buf64, "float16")
+	if err == nil {
+		t.Error("For an unknown precision, did not recieve error")
+	}
+}
+
+func TestNeuralNetworkLoadBinaryInvalid(t *testing.T) {
+	nn := newSaveTestNeuralNetwork(t)
+	var buf bytes.Buffer
+	err := nn.SaveBinary(&buf, PrecisionFloat32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	//every prefix of the data is truncated
+	for n := 0; n < len(data); n++ {
+		_, err = LoadBinary(bytes.NewReader(data[:n]))
+		if !errors.Is(err, ErrTruncated) {
+			t.Fatal("For the first", n, "of", len(data), "bytes", "Expected", ErrTruncated, "Got", err)
+		}
+	}
+
+	//changing any byte fails with one of the format errors
+	corrupted := make([]byte, len(data))
+	for i := range data {
+		copy(corrupted, data)
+		corrupted[i] ^= 0x5a
+		_, err = LoadBinary(bytes.NewReader(corrupted))
+		if !errors.Is(err, ErrBadMagic) && !errors.Is(err, ErrUnsupportedVersion) && !errors.Is(err, ErrTruncated) &&
+			!errors.Is(err, ErrChecksum) && !errors.Is(err, ErrCorrupt) {
+			t.Fatal("For a change to byte", i, "Expected a format error", "Got", err)
+		}
+	}
+	copy(corrupted, data)
+	corrupted[len(corrupted)-10] ^= 1
+	_, err = LoadBinary(bytes.NewReader(corrupted))
+	if !errors.Is(err, ErrChecksum) {
+		t.Error("For a change to the last parameter", "Expected", ErrChecksum, "Got", err)
+	}
+
+	var jsonBuf bytes.Buffer
+	nn.Save(&jsonBuf)
+	_, err = LoadBinary(&jsonBuf)
+	if !errors.Is(err, ErrBadMagic) {
+		t.Error("For JSON", "Expected", ErrBadMagic, "Got", err)
+	}
+	copy(corrupted, data)
+	binary.LittleEndian.PutUint16(corrupted[4:], binaryVersion+1)
+	_, err = LoadBinary(bytes.NewReader(corrupted))
+	if !errors.Is(err, ErrUnsupportedVersion) {
+		t.Error("For a newer version", "Expected", ErrUnsupportedVersion, "Got", err)
+	}
+	copy(corrupted, data)
+	binary.LittleEndian.PutUint32(corrupted[7:], 1000)
+	_, err = LoadBinary(bytes.NewReader(corrupted))
+	if !errors.Is(err, ErrCorrupt) {
+		t.Error("For 1000 layers", "Expected", ErrCorrupt, "Got", err)
+	}
+}
@@ -0,0 +1,83 @@
+package neuralnet
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/jyakimischak/neuralnet/optimizers"
+)
+
+//*************************************************************************************************************
+//params
+
+// gradientNorm will return the L2 norm of the gradients of every param taken together.
+func gradientNorm(params []optimizers.Param) float64 {
+	sumSquares := 0.0
+	for _, p := range params {
+		for _, g := range p.Grads {
+			sumSquares += g * g
+		}
+	}
+	return math.Sqrt(sumSquares)
+}
+
+// clipByValue will limit every gradient to [-clipValue, clipValue].
+func clipByValue(params []optimizers.Param, clipValue float64) {
+	for _, p := range params {
+		for i, g := range p.Grads {
+			p.Grads[i] = math.Max(-clipValue, math.Min(g, clipValue))
+		}
+	}
+}
+
+// clipByNorm will scale every gradient down by the same factor so their global L2 norm is at most maxNorm, and return
+// the norm from before the scaling.
+func clipByNorm(params []optimizers.Param, maxNorm float64) float64 {
+	norm := gradientNorm(params)
+	if norm <= maxNorm {
+		return norm
+	}
+	scale := maxNorm / norm
+	for _, p := range params {
+		for i := range p.Grads {
+			p.Grads[i] *= scale
+		}
+	}
+	return norm
+}
+
+// checkClip will return an error if a clip value or norm is negative.
+func checkClip(name string, value float64) error {
+	if value < 0 {
+		return fmt.Errorf("%s must be >= 0 and is: %f", name, value)
+	}
+	return nil
+}
+
+//*************************************************************************************************************
+//NeuralNetwork
+
+// GradientNorm will return the global L2 norm of every accumulated gradient in the network.
+func (nn *NeuralNetwork) GradientNorm() float64 {
+	return gradientNorm(nn.Params())
+}
+
+// ClipGradientsByValue will limit every accumulated gradient in the network to [-clipValue, clipValue].
+func (nn *NeuralNetwork) ClipGradientsByValue(clipValue float64) error {
+	err := checkClip("clipValue", clipValue)
+	if err != nil {
+		return err
+	}
+	clipByValue(nn.Params(), clipValue)
+	return nil
+}
+
+// ClipGradientsByNorm will scale the accumulated gradients of the network so their global L2 norm is at most maxNorm,
+// keeping their direction.  The norm from before clipping is returned.
+func (nn *NeuralNetwork) ClipGradientsByNorm(maxNorm float64) (float64, error) {
+	err := checkClip("maxNorm", maxNorm)
+	if err != nil {
+		return 0, err
+	}
+	return clipByNorm(nn.Params(), maxNorm), nil
+}
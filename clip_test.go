@@ -0,0 +1,137 @@
+package neuralnet
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jyakimischak/neuralnet/actfuncs"
+	"github.com/jyakimischak/neuralnet/losses"
+	"github.com/jyakimischak/neuralnet/optimizers"
+)
+
+// setTestGradients will zero the gradients of the network and then start the gradients of its first param with grads.
+func setTestGradients(nn *NeuralNetwork, grads ...float64) {
+	nn.ZeroGrads()
+	copy(nn.Params()[0].Grads, grads)
+}
+
+func TestClipGradients(t *testing.T) {
+	nn := newFitTestNeuralNetwork(t, 1, actfuncs.Sigmoid)
+
+	setTestGradients(nn, 3, -4)
+	if nn.GradientNorm() != 5 {
+		t.Error("For nn.GradientNorm()", "Expected", 5, "Got", nn.GradientNorm())
+	}
+
+	//a norm below the limit is left alone
+	norm, err := nn.ClipGradientsByNorm(10)
+	if err != nil || norm != 5 || nn.Params()[0].Grads[0] != 3 {
+		t.Error("For clipping a norm of 5 to 10", "Expected", 5, 3, "Got", norm, nn.Params()[0].Grads[0], err)
+	}
+	norm, err = nn.ClipGradientsByNorm(1)
+	if err != nil || norm != 5 {
+		t.Error("For the norm before clipping", "Expected", 5, "Got", norm, err)
+	}
+	grads := nn.Params()[0].Grads
+	if math.Abs(grads[0]-0.6) > 1e-12 || math.Abs(grads[1]+0.8) > 1e-12 || math.Abs(nn.GradientNorm()-1) > 1e-12 {
+		t.Error("For the gradients clipped to a norm of 1", "Expected", 0.6, -0.8, "Got", grads[0], grads[1])
+	}
+
+	setTestGradients(nn, 3, -4, 0.5)
+	err = nn.ClipGradientsByValue(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grads = nn.Params()[0].Grads
+	if grads[0] != 1 || grads[1] != -1 || grads[2] != 0.5 {
+		t.Error("For the gradients clipped to 1", "Expected", 1, -1, 0.5, "Got", grads[0], grads[1], grads[2])
+	}
+
+	err = nn.ClipGradientsByValue(-1)
+	if err == nil {
+		t.Error("For a negative clip value, did not recieve error")
+	}
+	_, err = nn.ClipGradientsByNorm(-1)
+	if err == nil {
+		t.Error("For a negative max norm, did not recieve error")
+	}
+}
+
+func TestFitClip(t *testing.T) {
+	//the large learning rate and targets far outside of tanh give steep gradients
+	dataset := Dataset{Inputs: [][]float64{{3, -2}, {-1, 4}}, Targets: [][]float64{{20}, {-20}}}
+	for _, c := range []struct {
+		clipValue float64
+		clipNorm  float64
+	}{
+		{0, 0},
+		{0.01, 0},
+		{0, 0.1},
+		{0.01, 0.1},
+	} {
+		nn := newFitTestNeuralNetwork(t, 1, actfuncs.Tanh)
+		before := nn.Snapshot()
+		var stats BatchStats
+		_, err := nn.Fit(dataset, FitConfig{
+			Epochs:     1,
+			Loss:       losses.MSE,
+			Optimizer:  optimizers.NewSGD(1),
+			ClipValue:  c.clipValue,
+			ClipNorm:   c.clipNorm,
+			OnBatchEnd: func(s BatchStats) { stats = s },
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		//the reported norm is from before clipping
+		unclipped := newFitTestNeuralNetwork(t, 1, actfuncs.Tanh)
+		unclipped.ZeroGrads()
+		batch := unclipped.newTrainingBatch(2)
+		batch.load(dataset, []int{0, 1})
+		batch.forwardBackward(losses.MSE)
+		if math.Abs(stats.GradNorm-unclipped.GradientNorm()) > 1e-12 {
+			t.Error("For BatchStats.GradNorm", "Expected", unclipped.GradientNorm(), "Got", stats.GradNorm)
+		}
+		if c.clipNorm > 0 && stats.GradNorm <= c.clipNorm {
+			t.Fatal("For the test gradients", "Expected a norm over", c.clipNorm, "Got", stats.GradNorm)
+		}
+
+		//with SGD at a learning rate of 1 the change of every param is its clipped gradient
+		err = unclipped.Restore(before)
+		if err != nil {
+			t.Fatal(err)
+		}
+		moved, maxMove := 0.0, 0.0
+		for iParam, p := range nn.Params() {
+			for i := range p.Values {
+				d := p.Values[i] - unclipped.Params()[iParam].Values[i]
+				moved += d * d
+				maxMove = math.Max(maxMove, math.Abs(d))
+			}
+		}
+		moved = math.Sqrt(moved)
+		if c.clipValue > 0 && maxMove > c.clipValue+1e-12 {
+			t.Error("For the largest change with ClipValue", c.clipValue, "Expected at most", c.clipValue, "Got", maxMove)
+		}
+		if c.clipNorm > 0 && moved > c.clipNorm+1e-12 {
+			t.Error("For the norm of the change with ClipNorm", c.clipNorm, "Expected at most", c.clipNorm, "Got", moved)
+		}
+		if c.clipValue == 0 && c.clipNorm > 0 && math.Abs(moved-c.clipNorm) > 1e-12 {
+			t.Error("For the norm of the change with only ClipNorm", "Expected", c.clipNorm, "Got", moved)
+		}
+		if c.clipValue == 0 && c.clipNorm == 0 && math.Abs(moved-stats.GradNorm) > 1e-9 {
+			t.Error("For the norm of the change without clipping", "Expected", stats.GradNorm, "Got", moved)
+		}
+	}
+
+	nn := newFitTestNeuralNetwork(t, 1, actfuncs.Tanh)
+	_, err := nn.Fit(dataset, FitConfig{Epochs: 1, Loss: losses.MSE, Optimizer: optimizers.NewSGD(1), ClipValue: -1})
+	if err == nil {
+		t.Error("For a negative ClipValue, did not recieve error")
+	}
+	_, err = nn.Fit(dataset, FitConfig{Epochs: 1, Loss: losses.MSE, Optimizer: optimizers.NewSGD(1), ClipNorm: -1})
+	if err == nil {
+		t.Error("For a negative ClipNorm, did not recieve error")
+	}
+}
@@ -0,0 +1,36 @@
+package neuralnet
+
+import "math/rand"
+
+// drawDropoutMask will fill mask with a new dropout mask for this layer drawn from rng, 0 for each output that is
+// dropped and 1 / (1 - Dropout) for each that is kept, so the expected value of every output is unchanged.
+func (nl *neuralLayer) drawDropoutMask(mask []float64, rng *rand.Rand) {
+	keep := 1 / (1 - nl.Dropout)
+	for i := range mask {
+		if rng.Float64() < nl.Dropout {
+			mask[i] = 0
+		} else {
+			mask[i] = keep
+		}
+	}
+}
+
+// applyDropoutMask will multiply each value by its entry in mask.
+func applyDropoutMask(values []float64, mask []float64) {
+	for i := range values {
+		values[i] *= mask[i]
+	}
+}
+
+// calcDropout will apply dropout to the outputs of a layer that has just been calculated by Calc.  In training mode a
+// new mask is drawn from the network's source, otherwise the mask is all 1 and the outputs are left alone.
+func (nn *NeuralNetwork) calcDropout(layer *neuralLayer) {
+	if nn.Mode != ModeTraining {
+		for i := range layer.DropoutMask {
+			layer.DropoutMask[i] = 1
+		}
+		return
+	}
+	layer.drawDropoutMask(layer.DropoutMask, nn.rng)
+	applyDropoutMask(layer.Outputs, layer.DropoutMask)
+}
@@ -0,0 +1,157 @@
+package neuralnet
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/jyakimischak/neuralnet/actfuncs"
+	"github.com/jyakimischak/neuralnet/losses"
+	"github.com/jyakimischak/neuralnet/optimizers"
+)
+
+// newDropoutNeuralNetwork will return a network with a single hidden layer of 100 neurons with the given dropout.
+func newDropoutNeuralNetwork(t *testing.T, dropout float64, options ...Option) *NeuralNetwork {
+	nn, err := NewNeuralNetwork(
+		InputLayerProps{NumInputs: 2},
+		[]HiddenLayerProps{
+			HiddenLayerProps{NumNeurons: 100, ActFunc: actfuncs.Sigmoid, Dropout: dropout},
+		},
+		OutputLayerProps{NumOutputs: 1, ActFunc: actfuncs.Sigmoid},
+		options...,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return nn
+}
+
+func TestDropoutCalc(t *testing.T) {
+	nn := newDropoutNeuralNetwork(t, 0.3, WithSeed(1))
+	inputs := []float64{0.4, -0.2}
+	predicted, _ := nn.Predict(inputs)
+
+	//inference mode is the default and leaves every output alone
+	copy(nn.InputLayer.Inputs, inputs)
+	nn.Calc()
+	if nn.OutputLayer.Outputs[0] != predicted[0] {
+		t.Error("For Calc in inference mode", "Expected", predicted[0], "Got", nn.OutputLayer.Outputs[0])
+	}
+
+	nn.Mode = ModeTraining
+	hl := nn.HiddenLayers[0]
+	numDropped := 0
+	for i := 0; i < 100; i++ {
+		nn.Calc()
+		for iNeuron, o := range hl.Outputs {
+			//kept outputs are scaled by 1 / (1 - 0.3)
+			expected := actfuncs.ApplyActFunc(actfuncs.Sigmoid, hl.OutBeforeAct[iNeuron]) / 0.7
+			if o == 0 {
+				numDropped++
+			} else if math.Abs(o-expected) > 1e-12 {
+				t.Fatal("For a kept output", "Expected", expected, "Got", o)
+			}
+		}
+	}
+	//30% of 10000 outputs
+	if numDropped < 2800 || numDropped > 3200 {
+		t.Error("For the number of dropped outputs", "Expected about", 3000, "Got", numDropped)
+	}
+
+	//Predict is not affected by the mode
+	predictedInTraining, _ := nn.Predict(inputs)
+	if predictedInTraining[0] != predicted[0] {
+		t.Error("For Predict in training mode", "Expected", predicted[0], "Got", predictedInTraining[0])
+	}
+
+	//back in inference mode the mask is all 1
+	nn.Mode = ModeInference
+	nn.Calc()
+	for _, m := range hl.DropoutMask {
+		if m != 1 {
+			t.Fatal("For DropoutMask in inference mode", "Expected", 1, "Got", m)
+		}
+	}
+	if nn.OutputLayer.Outputs[0] != predicted[0] {
+		t.Error("For Calc back in inference mode", "Expected", predicted[0], "Got", nn.OutputLayer.Outputs[0])
+	}
+}
+
+func TestDropoutSeed(t *testing.T) {
+	//the same seed gives the same masks
+	masks := [2][]float64{}
+	for i := range masks {
+		nn := newDropoutNeuralNetwork(t, 0.5, WithSeed(7))
+		nn.Mode = ModeTraining
+		nn.Calc()
+		masks[i] = append([]float64(nil), nn.HiddenLayers[0].DropoutMask...)
+	}
+	for i := range masks[0] {
+		if masks[0][i] != masks[1][i] {
+			t.Fatal("For DropoutMask with the same seed", "Expected", masks[0], "Got", masks[1])
+		}
+	}
+}
+
+func TestDropoutBackward(t *testing.T) {
+	//reseeding the source before every Calc keeps the mask the same so the gradients can be checked numerically
+	rng := rand.New(rand.NewSource(1))
+	nn := newDropoutNeuralNetwork(t, 0.5, WithRand(rng))
+	nn.Mode = ModeTraining
+	inputs := []float64{0.4, -0.2}
+	targets := []float64{1}
+	lossFn := func() float64 {
+		rng.Seed(3)
+		copy(nn.InputLayer.Inputs, inputs)
+		nn.Calc()
+		loss, _ := nn.Loss(losses.BinaryCrossEntropy, targets)
+		return loss
+	}
+
+	lossFn()
+	nn.ZeroGrads()
+	nn.BackwardLoss(losses.BinaryCrossEntropy, targets)
+	checkGradients(t, nn, lossFn)
+
+	//the weights of dropped neurons get no gradient
+	hl := nn.HiddenLayers[0]
+	for iNeuron, m := range hl.DropoutMask {
+		if m == 0 && hl.BiasGrads[iNeuron] != 0 {
+			t.Error("For the bias gradient of a dropped neuron", "Expected", 0, "Got", hl.BiasGrads[iNeuron])
+		}
+	}
+}
+
+func TestFitDropout(t *testing.T) {
+	nn := newDropoutNeuralNetwork(t, 0.2, WithSeed(1))
+	history, err := nn.Fit(newXORDataset(), FitConfig{
+		Epochs:    300,
+		Loss:      losses.BinaryCrossEntropy,
+		Optimizer: optimizers.NewAdam(0.05),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	loss, _ := nn.Evaluate(newXORDataset(), losses.BinaryCrossEntropy)
+	if loss >= history[0].Loss || loss > 0.2 {
+		t.Error("For XOR loss with dropout", "Expected it to drop below", 0.2, "from", history[0].Loss, "Got", loss)
+	}
+
+	//the same seed trains the same network
+	again := newDropoutNeuralNetwork(t, 0.2, WithSeed(1))
+	again.Fit(newXORDataset(), FitConfig{Epochs: 300, Loss: losses.BinaryCrossEntropy, Optimizer: optimizers.NewAdam(0.05)})
+	if !sameWeights(nn, again) {
+		t.Error("For two networks trained with dropout from the same seed", "Expected the same weights")
+	}
+
+	for _, dropout := range []float64{-0.1, 1} {
+		_, err = NewNeuralNetwork(
+			InputLayerProps{NumInputs: 2},
+			[]HiddenLayerProps{HiddenLayerProps{NumNeurons: 3, ActFunc: actfuncs.Tanh, Dropout: dropout}},
+			OutputLayerProps{NumOutputs: 1, ActFunc: actfuncs.Sigmoid},
+		)
+		if err == nil {
+			t.Error("For Dropout", dropout, "did not recieve error")
+		}
+	}
+}
@@ -0,0 +1,496 @@
+package neuralnet
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/jyakimischak/neuralnet/actfuncs"
+	"github.com/jyakimischak/neuralnet/losses"
+	"github.com/jyakimischak/neuralnet/optimizers"
+	"github.com/jyakimischak/neuralnet/schedules"
+)
+
+// Dataset is a set of samples to train on, Targets[i] being the expected outputs for Inputs[i].
+type Dataset struct {
+	Inputs  [][]float64
+	Targets [][]float64
+}
+
+// FitConfig is how Fit trains a network.  BatchSize is the number of samples whose gradients are averaged for each
+// step of the Optimizer, if it is less than 1 the whole dataset is one batch.  A network with a BatchNorm layer can
+// not have a batch of 1 sample, including the last one, since a single sample has no variance.  When Shuffle is true
+// the samples are put in a new random order, drawn from the network's source, at the start of every epoch.  The
+// callbacks are optional, epochs and batches are numbered from 0.
+//
+// When Validation is set the network is scored on it with the Loss at the end of every epoch, and when
+// EarlyStopping is set training can end before Epochs.  If the Optimizer's learning rate schedule is a
+// schedules.Observer it is given the validation loss, or the training loss without Validation, after every epoch.
+//
+// Before each step of the Optimizer every gradient is limited to [-ClipValue, ClipValue], then all of the gradients
+// are scaled down together so their global L2 norm is at most ClipNorm.  Either is off when it is 0.
+type FitConfig struct {
+	Epochs        int
+	BatchSize     int
+	Loss          string
+	Optimizer     optimizers.Optimizer
+	Shuffle       bool
+	ClipValue     float64
+	ClipNorm      float64
+	Validation    *Dataset
+	EarlyStopping *EarlyStopping
+	OnEpochStart  func(epoch int)
+	OnEpochEnd    func(stats EpochStats)
+	OnBatchEnd    func(stats BatchStats)
+}
+
+//MonitorLoss early stopping watches the training loss
+const MonitorLoss = "loss"
+
+//MonitorValidationLoss early stopping watches the validation loss
+const MonitorValidationLoss = "validationLoss"
+
+// EarlyStopping ends Fit once the Monitor metric has not improved by more than MinDelta for Patience epochs in a row.
+// When RestoreBest is true the weights and biases from the epoch with the best metric are put back when Fit returns.
+type EarlyStopping struct {
+	Monitor     string
+	Patience    int
+	MinDelta    float64
+	RestoreBest bool
+}
+
+// EpochStats is the mean loss over every sample in an epoch, calculated as each batch was trained, and the mean loss
+// over the validation dataset after the epoch, which is 0 when there is none.  RegularizationLoss is the L1 and L2
+// penalty averaged the same way as Loss, it is not included in Loss or ValidationLoss.
+type EpochStats struct {
+	Epoch              int
+	Loss               float64
+	RegularizationLoss float64
+	ValidationLoss     float64
+}
+
+// BatchStats is the mean loss over every sample in a batch and the L1 and L2 penalty, both from before the optimizer
+// step.  GradNorm is the global L2 norm of the gradients, including those of the penalties, before they are clipped.
+type BatchStats struct {
+	Epoch              int
+	Batch              int
+	Loss               float64
+	RegularizationLoss float64
+	GradNorm           float64
+}
+
+// Fit will train the network on the dataset for config.Epochs epochs.  Each epoch runs every sample through the
+// network in batches of config.BatchSize, the whole batch at once, averages the gradients of the loss over the batch,
+// adds the gradients of the L1 and L2 penalties, clips them and updates the weights and biases with config.Optimizer.
+// The stats of every epoch trained are returned.
+//
+// Fit changes the network so it must not be called at the same time as any other method.
+func (nn *NeuralNetwork) Fit(dataset Dataset, config FitConfig) ([]EpochStats, error) {
+	err := nn.checkFit(dataset, config)
+	if err != nil {
+		return nil, err
+	}
+
+	batchSize := config.BatchSize
+	if batchSize < 1 || batchSize > len(dataset.Inputs) {
+		batchSize = len(dataset.Inputs)
+	}
+	batch := nn.newTrainingBatch(batchSize)
+	params := nn.Params()
+	order := make([]int, len(dataset.Inputs))
+	for i := range order {
+		order[i] = i
+	}
+
+	var history []EpochStats
+	es := config.EarlyStopping
+	bestMetric := math.Inf(1)
+	var best *Snapshot
+	epochsWithoutImprovement := 0
+	for epoch := 0; epoch < config.Epochs; epoch++ {
+		if config.OnEpochStart != nil {
+			config.OnEpochStart(epoch)
+		}
+		if config.Shuffle {
+			nn.rng.Shuffle(len(order), func(i int, j int) { order[i], order[j] = order[j], order[i] })
+		}
+
+		epochLoss, epochRegularizationLoss := 0.0, 0.0
+		for iBatch, start := 0, 0; start < len(order); iBatch, start = iBatch+1, start+batchSize {
+			end := start + batchSize
+			if end > len(order) {
+				end = len(order)
+			}
+			batch.load(dataset, order[start:end])
+
+			nn.ZeroGrads()
+			batchLoss := batch.forwardBackward(config.Loss)
+			regularizationLoss := nn.RegularizationLoss()
+			nn.BackwardRegularization()
+			gradNorm := gradientNorm(params)
+			if config.ClipValue > 0 {
+				clipByValue(params, config.ClipValue)
+			}
+			if config.ClipNorm > 0 {
+				clipByNorm(params, config.ClipNorm)
+			}
+			err = config.Optimizer.Step(params)
+			if err != nil {
+				return history, err
+			}
+
+			epochLoss += batchLoss * float64(end-start)
+			epochRegularizationLoss += regularizationLoss * float64(end-start)
+			if config.OnBatchEnd != nil {
+				config.OnBatchEnd(BatchStats{
+					Epoch:              epoch,
+					Batch:              iBatch,
+					Loss:               batchLoss,
+					RegularizationLoss: regularizationLoss,
+					GradNorm:           gradNorm,
+				})
+			}
+		}
+
+		stats := EpochStats{
+			Epoch:              epoch,
+			Loss:               epochLoss / float64(len(order)),
+			RegularizationLoss: epochRegularizationLoss / float64(len(order)),
+		}
+		if config.Validation != nil {
+			stats.ValidationLoss, err = nn.Evaluate(*config.Validation, config.Loss)
+			if err != nil {
+				return history, err
+			}
+		}
+		history = append(history, stats)
+		nn.observeSchedule(config, stats)
+		if config.OnEpochEnd != nil {
+			config.OnEpochEnd(stats)
+		}
+
+		if es == nil {
+			continue
+		}
+		metric := stats.Loss
+		if es.Monitor == MonitorValidationLoss {
+			metric = stats.ValidationLoss
+		}
+		if metric < bestMetric-es.MinDelta {
+			bestMetric = metric
+			epochsWithoutImprovement = 0
+			if es.RestoreBest {
+				best = nn.Snapshot()
+			}
+		} else {
+			epochsWithoutImprovement++
+			if epochsWithoutImprovement >= es.Patience {
+				break
+			}
+		}
+	}
+
+	if best != nil {
+		err = nn.Restore(best)
+		if err != nil {
+			return history, err
+		}
+	}
+	return history, nil
+}
+
+// observeSchedule will pass the validation loss, or the training loss when there is no validation dataset, to the
+// optimizer's learning rate schedule if it follows a metric.
+func (nn *NeuralNetwork) observeSchedule(config FitConfig, stats EpochStats) {
+	scheduled, ok := config.Optimizer.(optimizers.Scheduled)
+	if !ok {
+		return
+	}
+	observer, ok := scheduled.LearningRateSchedule().(schedules.Observer)
+	if !ok {
+		return
+	}
+	if config.Validation != nil {
+		observer.Observe(stats.ValidationLoss)
+	} else {
+		observer.Observe(stats.Loss)
+	}
+}
+
+// Evaluate will return the mean of the given loss function over every sample in the dataset.
+func (nn *NeuralNetwork) Evaluate(dataset Dataset, lossFunc string) (float64, error) {
+	err := nn.checkDataset(dataset)
+	if err != nil {
+		return 0, err
+	}
+	value, _, ok := losses.Lookup(lossFunc)
+	if !ok {
+		return 0, fmt.Errorf("Unknown loss function: %s", lossFunc)
+	}
+	outputs, err := nn.PredictBatch(dataset.Inputs)
+	if err != nil {
+		return 0, err
+	}
+	loss := 0.0
+	for i := range outputs {
+		loss += value(outputs[i], dataset.Targets[i])
+	}
+	return loss / float64(len(outputs)), nil
+}
+
+// checkFit will return an error if the network, the datasets or the config can not be used by Fit.
+func (nn *NeuralNetwork) checkFit(dataset Dataset, config FitConfig) error {
+	isValid, invalidMsg := nn.IsValid()
+	if !isValid {
+		return errors.New(invalidMsg)
+	}
+	err := nn.checkDataset(dataset)
+	if err != nil {
+		return err
+	}
+	if config.Validation != nil {
+		err = nn.checkDataset(*config.Validation)
+		if err != nil {
+			return fmt.Errorf("config.Validation: %v", err)
+		}
+	}
+	if config.Epochs < 1 {
+		return fmt.Errorf("config.Epochs must be > 0 and is: %d", config.Epochs)
+	}
+	if !losses.IsValidLoss(config.Loss) {
+		return fmt.Errorf("Unknown loss function: %s", config.Loss)
+	}
+	if config.Optimizer == nil {
+		return errors.New("config.Optimizer must not be nil")
+	}
+	batchSize := config.BatchSize
+	if batchSize < 1 || batchSize > len(dataset.Inputs) {
+		batchSize = len(dataset.Inputs)
+	}
+	if batchSize == 1 || len(dataset.Inputs)%batchSize == 1 {
+		for _, layer := range nn.HiddenLayers {
+			if layer.Kind == LayerKindBatchNorm {
+				return fmt.Errorf("config.BatchSize can not leave a batch of 1 sample with a %s layer: %d", LayerKindBatchNorm,
+					config.BatchSize)
+			}
+		}
+	}
+	err = checkClip("config.ClipValue", config.ClipValue)
+	if err != nil {
+		return err
+	}
+	err = checkClip("config.ClipNorm", config.ClipNorm)
+	if err != nil {
+		return err
+	}
+	if es := config.EarlyStopping; es != nil {
+		if es.Monitor != MonitorLoss && es.Monitor != MonitorValidationLoss {
+			return fmt.Errorf("Unknown early stopping monitor: %s", es.Monitor)
+		}
+		if es.Monitor == MonitorValidationLoss && config.Validation == nil {
+			return errors.New("config.Validation must be set to monitor the validation loss")
+		}
+		if es.Patience < 1 {
+			return fmt.Errorf("config.EarlyStopping.Patience must be > 0 and is: %d", es.Patience)
+		}
+	}
+	return nil
+}
+
+// checkDataset will return an error if the dataset is empty or does not fit the inputs and outputs of the network.
+func (nn *NeuralNetwork) checkDataset(dataset Dataset) error {
+	if len(dataset.Inputs) == 0 {
+		return errors.New("dataset must have at least one sample")
+	}
+	if len(dataset.Inputs) != len(dataset.Targets) {
+		return fmt.Errorf("len(dataset.Targets) must be %d and is: %d", len(dataset.Inputs), len(dataset.Targets))
+	}
+	for i := range dataset.Inputs {
+		err := nn.checkInputs(dataset.Inputs[i])
+		if err != nil {
+			return fmt.Errorf("dataset.Inputs[%d]: %v", i, err)
+		}
+		if len(dataset.Targets[i]) != nn.OutputLayer.NumNeurons {
+			return fmt.Errorf("dataset.Targets[%d]: len(targets) must be %d and is: %d", i, nn.OutputLayer.NumNeurons, len(dataset.Targets[i]))
+		}
+	}
+	return nil
+}
+
+//*************************************************************************************************************
+//trainingBatch
+
+// trainingBatch holds a batch of samples and the activations and gradients of every layer for them, each stored as
+// a row-major matrix with one row per sample.  It lets Fit run a whole batch through each layer with the matrix
+// kernels instead of one sample at a time.
+type trainingBatch struct {
+	layers  []*neuralLayer
+	rng     *rand.Rand
+	numRows int
+	inputs  []float64
+	targets []float64
+	//per layer, the weighted sums, the outputs, the gradients of the loss with respect to the outputs, the deltas and
+	//the dropout masks, which are nil for layers without dropout
+	outBeforeAct [][]float64
+	outputs      [][]float64
+	outputGrads  [][]float64
+	deltas       [][]float64
+	dropoutMasks [][]float64
+	//per normalization layer, the normalized inputs and the inverse standard deviations, nil for dense layers
+	xhat   [][]float64
+	invStd [][]float64
+}
+
+// newTrainingBatch will return a trainingBatch with room for maxRows samples.
+func (nn *NeuralNetwork) newTrainingBatch(maxRows int) *trainingBatch {
+	b := &trainingBatch{
+		rng:     nn.rng,
+		inputs:  make([]float64, maxRows*nn.InputLayer.NumInputs),
+		targets: make([]float64, maxRows*nn.OutputLayer.NumNeurons),
+	}
+	for layer := nn.InputLayer; layer != nil; layer = layer.NextLayer {
+		b.layers = append(b.layers, layer)
+		b.outBeforeAct = append(b.outBeforeAct, make([]float64, maxRows*layer.NumNeurons))
+		b.outputs = append(b.outputs, make([]float64, maxRows*layer.NumNeurons))
+		b.outputGrads = append(b.outputGrads, make([]float64, maxRows*layer.NumNeurons))
+		b.deltas = append(b.deltas, make([]float64, maxRows*layer.NumNeurons))
+		var dropoutMask []float64
+		if layer.Dropout > 0 {
+			dropoutMask = make([]float64, maxRows*layer.NumNeurons)
+		}
+		b.dropoutMasks = append(b.dropoutMasks, dropoutMask)
+		var xhat, invStd []float64
+		switch layer.Kind {
+		case LayerKindBatchNorm:
+			//the batch is normalized per neuron
+			xhat = make([]float64, maxRows*layer.NumNeurons)
+			invStd = make([]float64, layer.NumNeurons)
+		case LayerKindLayerNorm:
+			//each row is normalized on its own
+			xhat = make([]float64, maxRows*layer.NumNeurons)
+			invStd = make([]float64, maxRows)
+		}
+		b.xhat = append(b.xhat, xhat)
+		b.invStd = append(b.invStd, invStd)
+	}
+	return b
+}
+
+// load will copy the samples of the dataset with the given indexes into the batch.
+func (b *trainingBatch) load(dataset Dataset, indexes []int) {
+	b.numRows = len(indexes)
+	numInputs := b.layers[0].NumInputs
+	numOutputs := b.layers[len(b.layers)-1].NumNeurons
+	for iRow, iSample := range indexes {
+		copy(b.inputs[iRow*numInputs:(iRow+1)*numInputs], dataset.Inputs[iSample])
+		copy(b.targets[iRow*numOutputs:(iRow+1)*numOutputs], dataset.Targets[iSample])
+	}
+}
+
+// forwardBackward will run the batch through every layer, accumulate the gradients of the mean loss over the batch
+// on every layer and return the mean loss.
+func (b *trainingBatch) forwardBackward(lossFunc string) float64 {
+	n := b.numRows
+	layerInputs := b.inputs[:n*b.layers[0].NumInputs]
+	for i, layer := range b.layers {
+		if layer.Kind == LayerKindDense {
+			layer.weightedSums(layerInputs, n, b.outBeforeAct[i][:n*layer.NumNeurons])
+		} else {
+			layer.normalize(layerInputs, n, true, b.xhat[i], b.invStd[i], b.outBeforeAct[i][:n*layer.NumNeurons])
+		}
+		layer.applyActFunc(b.outBeforeAct[i][:n*layer.NumNeurons], n, b.outputs[i][:n*layer.NumNeurons])
+		if layer.Dropout > 0 {
+			layer.drawDropoutMask(b.dropoutMasks[i][:n*layer.NumNeurons], b.rng)
+			applyDropoutMask(b.outputs[i][:n*layer.NumNeurons], b.dropoutMasks[i])
+		}
+		layerInputs = b.outputs[i][:n*layer.NumNeurons]
+	}
+
+	//the loss of every row, and its gradient scaled by 1 / n so the accumulated gradients are for the mean loss
+	iOutput := len(b.layers) - 1
+	ol := b.layers[iOutput]
+	value, gradient, _ := losses.Lookup(lossFunc)
+	loss := 0.0
+	fused := false
+	for iRow := 0; iRow < n; iRow++ {
+		outputs := b.outputs[iOutput][iRow*ol.NumNeurons : (iRow+1)*ol.NumNeurons]
+		targets := b.targets[iRow*ol.NumNeurons : (iRow+1)*ol.NumNeurons]
+		loss += value(outputs, targets)
+		deltas := b.deltas[iOutput][iRow*ol.NumNeurons : (iRow+1)*ol.NumNeurons]
+		fused = ol.fusedLossDeltas(lossFunc, outputs, targets, deltas)
+		if fused {
+			for i := range deltas {
+				deltas[i] /= float64(n)
+			}
+			continue
+		}
+		grads := b.outputGrads[iOutput][iRow*ol.NumNeurons : (iRow+1)*ol.NumNeurons]
+		gradient(outputs, targets, grads)
+		for i := range grads {
+			grads[i] /= float64(n)
+		}
+	}
+
+	for i := iOutput; i >= 0; i-- {
+		layer := b.layers[i]
+		if i != iOutput || !fused {
+			layer.batchDeltas(b.outBeforeAct[i], b.outputs[i], b.outputGrads[i], b.dropoutMasks[i], n, b.deltas[i])
+		}
+		layerInputs := b.inputs
+		var inputGrads []float64
+		if i > 0 {
+			layerInputs = b.outputs[i-1]
+			inputGrads = b.outputGrads[i-1]
+		}
+		if layer.Kind == LayerKindDense {
+			layer.backwardBatchDeltas(layerInputs, b.deltas[i], n, inputGrads)
+		} else {
+			layer.backwardNorm(b.deltas[i], n, b.xhat[i], b.invStd[i], true, inputGrads)
+		}
+	}
+	return loss / float64(n)
+}
+
+// batchDeltas will set the first numRows rows of deltas to the gradient of the loss with respect to OutBeforeAct
+// given the gradient with respect to the outputs, for every row of a batch.  dropoutMask is nil if the layer has no
+// dropout.
+func (nl *neuralLayer) batchDeltas(outBeforeAct []float64, outputs []float64, outputGrads []float64,
+	dropoutMask []float64, numRows int, deltas []float64) {
+	if nl.ActFunc == actfuncs.Softmax {
+		for iRow := 0; iRow < numRows; iRow++ {
+			row := outputs[iRow*nl.NumNeurons : (iRow+1)*nl.NumNeurons]
+			actfuncs.ApplySoftmaxJacobian(row, outputGrads[iRow*nl.NumNeurons:(iRow+1)*nl.NumNeurons],
+				deltas[iRow*nl.NumNeurons:(iRow+1)*nl.NumNeurons])
+		}
+		return
+	}
+	_, derivative, ok := actfuncs.LookupAlpha(nl.ActFunc, nl.ActAlpha)
+	for i := 0; i < numRows*nl.NumNeurons; i++ {
+		deltas[i] = outputGrads[i]
+		if dropoutMask != nil {
+			deltas[i] *= dropoutMask[i]
+		}
+		if ok {
+			deltas[i] *= derivative(outBeforeAct[i])
+		}
+	}
+}
+
+// backwardBatchDeltas will accumulate the gradients of every neuron in this layer from the first numRows rows of
+// inputs and deltas and, unless inputGrads is nil, set inputGrads to the gradient of the loss with respect to the
+// inputs of every row.
+func (nl *neuralLayer) backwardBatchDeltas(inputs []float64, deltas []float64, numRows int, inputGrads []float64) {
+	matTransMulAdd(deltas, numRows, nl.NumNeurons, inputs, nl.NumInputs, nl.WeightGrads)
+	for iRow := 0; iRow < numRows; iRow++ {
+		axpy(1, deltas[iRow*nl.NumNeurons:(iRow+1)*nl.NumNeurons], nl.BiasGrads)
+	}
+	if inputGrads == nil {
+		return
+	}
+	inputGrads = inputGrads[:numRows*nl.NumInputs]
+	for i := range inputGrads {
+		inputGrads[i] = 0
+	}
+	matMulAdd(deltas, numRows, nl.NumNeurons, nl.Weights, nl.NumInputs, inputGrads)
+}
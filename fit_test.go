@@ -0,0 +1,263 @@
+package neuralnet
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jyakimischak/neuralnet/actfuncs"
+	"github.com/jyakimischak/neuralnet/losses"
+	"github.com/jyakimischak/neuralnet/optimizers"
+	"github.com/jyakimischak/neuralnet/schedules"
+)
+
+// newXORDataset will return the 4 samples of exclusive or.
+func newXORDataset() Dataset {
+	return Dataset{
+		Inputs:  [][]float64{{0, 0}, {0, 1}, {1, 0}, {1, 1}},
+		Targets: [][]float64{{0}, {1}, {1}, {0}},
+	}
+}
+
+// newFitTestNeuralNetwork will return a seeded network with 2 inputs, a tanh hidden layer and the given output layer.
+func newFitTestNeuralNetwork(t *testing.T, numOutputs int, actFunc string) *NeuralNetwork {
+	nn, err := NewNeuralNetwork(
+		InputLayerProps{NumInputs: 2},
+		[]HiddenLayerProps{
+			HiddenLayerProps{NumNeurons: 4, ActFunc: actfuncs.Tanh},
+		},
+		OutputLayerProps{NumOutputs: numOutputs, ActFunc: actFunc},
+		WithSeed(1),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return nn
+}
+
+func TestFit(t *testing.T) {
+	nn := newFitTestNeuralNetwork(t, 1, actfuncs.Sigmoid)
+
+	numEpochStarts, numBatches := 0, 0
+	var lastEpoch EpochStats
+	history, err := nn.Fit(newXORDataset(), FitConfig{
+		Epochs:       500,
+		BatchSize:    2,
+		Loss:         losses.BinaryCrossEntropy,
+		Optimizer:    optimizers.NewAdam(0.05),
+		Shuffle:      true,
+		OnEpochStart: func(epoch int) { numEpochStarts++ },
+		OnEpochEnd:   func(stats EpochStats) { lastEpoch = stats },
+		OnBatchEnd: func(stats BatchStats) {
+			if stats.Batch > 1 {
+				t.Error("For BatchStats.Batch", "Expected 0 or 1", "Got", stats.Batch)
+			}
+			numBatches++
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(history) != 500 || numEpochStarts != 500 || numBatches != 1000 {
+		t.Error("For epochs, epoch starts and batches", "Expected", 500, 500, 1000, "Got", len(history), numEpochStarts, numBatches)
+	}
+	if lastEpoch != history[499] || lastEpoch.Epoch != 499 {
+		t.Error("For the last OnEpochEnd", "Expected", history[499], "Got", lastEpoch)
+	}
+	if history[499].Loss >= history[0].Loss || history[499].Loss > 0.1 {
+		t.Error("For XOR loss", "Expected it to drop below", 0.1, "from", history[0].Loss, "Got", history[499].Loss)
+	}
+
+	dataset := newXORDataset()
+	for i := range dataset.Inputs {
+		outputs, _ := nn.Predict(dataset.Inputs[i])
+		if math.Abs(outputs[0]-dataset.Targets[i][0]) > 0.2 {
+			t.Error("For XOR", dataset.Inputs[i], "Expected", dataset.Targets[i][0], "Got", outputs[0])
+		}
+	}
+}
+
+func TestFitMatchesBackward(t *testing.T) {
+	//one full batch step of SGD must match the gradients of every sample from BackwardLoss averaged over the batch
+	dataset := Dataset{
+		Inputs:  [][]float64{{0.1, 0.7}, {-0.4, 0.3}, {0.9, -0.8}},
+		Targets: [][]float64{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}},
+	}
+	for _, c := range []struct {
+		actFunc  string
+		lossFunc string
+	}{
+		{actfuncs.Softmax, losses.CategoricalCrossEntropy},
+		{actfuncs.Softmax, losses.MSE},
+		{actfuncs.Sigmoid, losses.BinaryCrossEntropy},
+		{actfuncs.Tanh, losses.Huber},
+	} {
+		fitted := newFitTestNeuralNetwork(t, 3, c.actFunc)
+		history, err := fitted.Fit(dataset, FitConfig{Epochs: 1, Loss: c.lossFunc, Optimizer: optimizers.NewSGD(0.5)})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		nn := newFitTestNeuralNetwork(t, 3, c.actFunc)
+		nn.ZeroGrads()
+		loss := 0.0
+		for i := range dataset.Inputs {
+			copy(nn.InputLayer.Inputs, dataset.Inputs[i])
+			nn.Calc()
+			sampleLoss, _ := nn.Loss(c.lossFunc, dataset.Targets[i])
+			loss += sampleLoss / 3
+			nn.BackwardLoss(c.lossFunc, dataset.Targets[i])
+		}
+		nn.UpdateWeights(0.5 / 3)
+
+		if math.Abs(history[0].Loss-loss) > 1e-12 {
+			t.Error("For", c.lossFunc, "epoch loss", "Expected", loss, "Got", history[0].Loss)
+		}
+		fittedParams := fitted.Params()
+		for iParam, p := range nn.Params() {
+			for i := range p.Values {
+				if math.Abs(p.Values[i]-fittedParams[iParam].Values[i]) > 1e-12 {
+					t.Errorf("For %s with %s %s[%d] Expected %f Got %f", c.actFunc, c.lossFunc, p.Name, i, p.Values[i], fittedParams[iParam].Values[i])
+				}
+			}
+		}
+	}
+}
+
+func TestFitInvalid(t *testing.T) {
+	nn := newFitTestNeuralNetwork(t, 1, actfuncs.Sigmoid)
+	config := FitConfig{Epochs: 1, Loss: losses.MSE, Optimizer: optimizers.NewSGD(0.1)}
+
+	_, err := nn.Fit(Dataset{}, config)
+	if err == nil {
+		t.Error("For an empty dataset, did not recieve error")
+	}
+	_, err = nn.Fit(Dataset{Inputs: [][]float64{{0, 0}}, Targets: [][]float64{{0}, {1}}}, config)
+	if err == nil {
+		t.Error("For mismatched Inputs and Targets, did not recieve error")
+	}
+	_, err = nn.Fit(Dataset{Inputs: [][]float64{{0}}, Targets: [][]float64{{0}}}, config)
+	if err == nil {
+		t.Error("For the wrong number of inputs, did not recieve error")
+	}
+	_, err = nn.Fit(Dataset{Inputs: [][]float64{{0, 0}}, Targets: [][]float64{{0, 1}}}, config)
+	if err == nil {
+		t.Error("For the wrong number of targets, did not recieve error")
+	}
+
+	_, err = nn.Fit(newXORDataset(), FitConfig{Epochs: 0, Loss: losses.MSE, Optimizer: optimizers.NewSGD(0.1)})
+	if err == nil {
+		t.Error("For 0 epochs, did not recieve error")
+	}
+	_, err = nn.Fit(newXORDataset(), FitConfig{Epochs: 1, Loss: "invalid", Optimizer: optimizers.NewSGD(0.1)})
+	if err == nil {
+		t.Error("For an unknown loss function, did not recieve error")
+	}
+	_, err = nn.Fit(newXORDataset(), FitConfig{Epochs: 1, Loss: losses.MSE})
+	if err == nil {
+		t.Error("For a nil optimizer, did not recieve error")
+	}
+}
+
+func TestFitEarlyStopping(t *testing.T) {
+	//the validation targets are the opposite of the training targets so the validation loss gets worse with training
+	dataset := Dataset{Inputs: [][]float64{{0, 1}, {1, 0}}, Targets: [][]float64{{1}, {0}}}
+	validation := Dataset{Inputs: [][]float64{{0, 1}, {1, 0}}, Targets: [][]float64{{0}, {1}}}
+	nn := newFitTestNeuralNetwork(t, 1, actfuncs.Sigmoid)
+	history, err := nn.Fit(dataset, FitConfig{
+		Epochs:        1000,
+		Loss:          losses.BinaryCrossEntropy,
+		Optimizer:     optimizers.NewSGD(0.5),
+		Validation:    &validation,
+		EarlyStopping: &EarlyStopping{Monitor: MonitorValidationLoss, Patience: 5, RestoreBest: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(history) >= 1000 {
+		t.Fatal("For early stopping", "Expected fewer than", 1000, "epochs", "Got", len(history))
+	}
+	best := history[0]
+	for _, stats := range history {
+		if stats.ValidationLoss < best.ValidationLoss {
+			best = stats
+		}
+	}
+	if best.Epoch != len(history)-6 {
+		t.Error("For the epoch of the best validation loss", "Expected", len(history)-6, "Got", best.Epoch)
+	}
+	validationLoss, err := nn.Evaluate(validation, losses.BinaryCrossEntropy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if validationLoss != best.ValidationLoss {
+		t.Error("For the validation loss after restoring the best weights", "Expected", best.ValidationLoss, "Got", validationLoss)
+	}
+
+	//nothing improves by more than MinDelta after the first epoch
+	history, err = nn.Fit(dataset, FitConfig{
+		Epochs:        100,
+		Loss:          losses.BinaryCrossEntropy,
+		Optimizer:     optimizers.NewSGD(0.5),
+		EarlyStopping: &EarlyStopping{Monitor: MonitorLoss, Patience: 3, MinDelta: 1e9},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 4 {
+		t.Error("For epochs with MinDelta 1e9 and Patience 3", "Expected", 4, "Got", len(history))
+	}
+
+	_, err = nn.Fit(dataset, FitConfig{
+		Epochs:        1,
+		Loss:          losses.MSE,
+		Optimizer:     optimizers.NewSGD(0.5),
+		EarlyStopping: &EarlyStopping{Monitor: MonitorValidationLoss, Patience: 1},
+	})
+	if err == nil {
+		t.Error("For monitoring the validation loss without a validation dataset, did not recieve error")
+	}
+	_, err = nn.Fit(dataset, FitConfig{
+		Epochs:        1,
+		Loss:          losses.MSE,
+		Optimizer:     optimizers.NewSGD(0.5),
+		EarlyStopping: &EarlyStopping{Monitor: MonitorLoss},
+	})
+	if err == nil {
+		t.Error("For Patience 0, did not recieve error")
+	}
+	_, err = nn.Fit(dataset, FitConfig{
+		Epochs:     1,
+		Loss:       losses.MSE,
+		Optimizer:  optimizers.NewSGD(0.5),
+		Validation: &Dataset{Inputs: [][]float64{{0}}, Targets: [][]float64{{0}}},
+	})
+	if err == nil {
+		t.Error("For an invalid validation dataset, did not recieve error")
+	}
+}
+
+func TestFitSchedule(t *testing.T) {
+	//the learning rate is far too high for the plateau to be left without reducing it
+	nn := newFitTestNeuralNetwork(t, 1, actfuncs.Sigmoid)
+	plateau := schedules.NewReduceOnPlateau(3)
+	opt := optimizers.NewSGD(50)
+	opt.Schedule = plateau
+	validation := newXORDataset()
+	history, err := nn.Fit(newXORDataset(), FitConfig{
+		Epochs:     200,
+		Loss:       losses.BinaryCrossEntropy,
+		Optimizer:  opt,
+		Validation: &validation,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plateau.Reductions() == 0 {
+		t.Error("For ReduceOnPlateau Reductions", "Expected more than", 0, "Got", plateau.Reductions())
+	}
+	if history[199].ValidationLoss >= history[0].ValidationLoss {
+		t.Error("For the validation loss", "Expected it to drop from", history[0].ValidationLoss, "Got", history[199].ValidationLoss)
+	}
+}
@@ -0,0 +1,130 @@
+package neuralnet
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/jyakimischak/neuralnet/losses"
+)
+
+// gradCheckStep is how far each parameter is moved either way for the central finite difference.
+const gradCheckStep = 1e-6
+
+// gradCheckMinScale is the smallest denominator of a relative error, so gradients that are both about 0 agree.
+const gradCheckMinScale = 1e-8
+
+// GradCheckReport is the relative error between the gradients from backpropagation and from central finite
+// differences of a network, for every layer starting from the input layer.
+type GradCheckReport struct {
+	MaxRelativeError float64
+	Layers           []GradCheckLayer
+}
+
+// GradCheckLayer is the relative error of the gradients of one layer.  Neurons[i] is the largest relative error of
+// the weights and bias of neuron i, or its Gamma and Beta for a normalization layer.
+type GradCheckLayer struct {
+	Layer            int
+	Kind             string
+	ActFunc          string
+	MaxRelativeError float64
+	Neurons          []float64
+}
+
+// GradCheck will compare the gradients of the loss between the network's outputs for the inputs and the targets,
+// found by backpropagation, with central finite differences for every weight and bias in the network.  A relative
+// error over about 1e-5 usually means the derivative of an activation function or the gradient of a loss function
+// is wrong.  Dropout is not applied while checking.
+//
+// The accumulated gradients and Mode of the network are put back when GradCheck returns, the outputs of every layer
+// are left from the last calculation.
+func GradCheck(nn *NeuralNetwork, inputs []float64, targets []float64, lossFunc string) (*GradCheckReport, error) {
+	isValid, invalidMsg := nn.IsValid()
+	if !isValid {
+		return nil, errors.New(invalidMsg)
+	}
+	err := nn.checkInputs(inputs)
+	if err != nil {
+		return nil, err
+	}
+	if len(targets) != nn.OutputLayer.NumNeurons {
+		return nil, fmt.Errorf("len(targets) must be %d and is: %d", nn.OutputLayer.NumNeurons, len(targets))
+	}
+	if !losses.IsValidLoss(lossFunc) {
+		return nil, fmt.Errorf("Unknown loss function: %s", lossFunc)
+	}
+
+	mode := nn.Mode
+	nn.Mode = ModeInference
+	defer func() { nn.Mode = mode }()
+	params := nn.Params()
+	savedGrads := make([][]float64, len(params))
+	for i, p := range params {
+		savedGrads[i] = append([]float64(nil), p.Grads...)
+	}
+	defer func() {
+		for i, p := range params {
+			copy(p.Grads, savedGrads[i])
+		}
+	}()
+
+	lossFn := func() float64 {
+		copy(nn.InputLayer.Inputs, inputs)
+		nn.Calc()
+		loss, _ := nn.Loss(lossFunc, targets)
+		return loss
+	}
+	lossFn()
+	nn.ZeroGrads()
+	err = nn.BackwardLoss(lossFunc, targets)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &GradCheckReport{}
+	iLayer := 0
+	for layer := nn.InputLayer; layer != nil; layer = layer.NextLayer {
+		lr := GradCheckLayer{Layer: iLayer, Kind: layer.Kind, ActFunc: layer.ActFunc, Neurons: make([]float64, layer.NumNeurons)}
+		if layer.Kind == LayerKindDense {
+			weightErrors := relativeErrors(layer.Weights, layer.WeightGrads, lossFn)
+			biasErrors := relativeErrors(layer.Biases, layer.BiasGrads, lossFn)
+			for j := range lr.Neurons {
+				lr.Neurons[j] = biasErrors[j]
+				for _, e := range weightErrors[j*layer.NumInputs : (j+1)*layer.NumInputs] {
+					lr.Neurons[j] = math.Max(lr.Neurons[j], e)
+				}
+			}
+		} else {
+			gammaErrors := relativeErrors(layer.Gamma, layer.GammaGrads, lossFn)
+			betaErrors := relativeErrors(layer.Beta, layer.BetaGrads, lossFn)
+			for j := range lr.Neurons {
+				lr.Neurons[j] = math.Max(gammaErrors[j], betaErrors[j])
+			}
+		}
+		for _, e := range lr.Neurons {
+			lr.MaxRelativeError = math.Max(lr.MaxRelativeError, e)
+		}
+		report.MaxRelativeError = math.Max(report.MaxRelativeError, lr.MaxRelativeError)
+		report.Layers = append(report.Layers, lr)
+		iLayer++
+	}
+	return report, nil
+}
+
+// relativeErrors will return the relative error between each gradient and a central finite difference of the loss
+// function over the matching parameter.
+func relativeErrors(params []float64, grads []float64, lossFn func() float64) []float64 {
+	errs := make([]float64, len(params))
+	for i := range params {
+		orig := params[i]
+		params[i] = orig + gradCheckStep
+		lossPlus := lossFn()
+		params[i] = orig - gradCheckStep
+		lossMinus := lossFn()
+		params[i] = orig
+		numeric := (lossPlus - lossMinus) / (2 * gradCheckStep)
+		scale := math.Max(math.Max(math.Abs(numeric), math.Abs(grads[i])), gradCheckMinScale)
+		errs[i] = math.Abs(numeric-grads[i]) / scale
+	}
+	return errs
+}
@@ -0,0 +1,154 @@
+package neuralnet
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jyakimischak/neuralnet/actfuncs"
+	"github.com/jyakimischak/neuralnet/losses"
+)
+
+// newGradCheckTestNeuralNetwork will return a seeded network with 3 inputs, two hidden layers with the given activation
+// function and a sigmoid output layer.
+func newGradCheckTestNeuralNetwork(t *testing.T, actFunc string) *NeuralNetwork {
+	nn, err := NewNeuralNetwork(
+		InputLayerProps{NumInputs: 3},
+		[]HiddenLayerProps{
+			HiddenLayerProps{NumNeurons: 4, ActFunc: actFunc},
+			HiddenLayerProps{NumNeurons: 3, ActFunc: actFunc},
+		},
+		OutputLayerProps{NumOutputs: 2, ActFunc: actfuncs.Sigmoid},
+		WithSeed(1),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return nn
+}
+
+func TestGradCheck(t *testing.T) {
+	inputs := []float64{0.4, -0.2, 0.7}
+	targets := []float64{1, 0}
+	for _, actFunc := range []string{actfuncs.NoActFunc, actfuncs.Sigmoid, actfuncs.ReLU, actfuncs.LeakyReLU,
+		actfuncs.ELU, actfuncs.Tanh, actfuncs.Softplus, actfuncs.GELU, actfuncs.Swish} {
+		nn := newGradCheckTestNeuralNetwork(t, actFunc)
+		//the second hidden layer checks an alpha other than the default for LeakyReLU and ELU
+		nn.HiddenLayers[1].ActAlpha = 0.5
+		report, err := GradCheck(nn, inputs, targets, losses.BinaryCrossEntropy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(report.Layers) != 4 || len(report.Layers[1].Neurons) != 4 || report.Layers[2].ActFunc != actFunc {
+			t.Fatal("For the layers of the report", "Expected", 4, "layers with", 4, "neurons in layer 1", "Got", report.Layers)
+		}
+		if report.MaxRelativeError > 1e-5 {
+			t.Error("For GradCheck with", actFunc, "Expected a relative error under", 1e-5, "Got", report.MaxRelativeError, report.Layers)
+		}
+	}
+
+	//the loss functions and the softmax output layer
+	for _, lossFunc := range []string{losses.MSE, losses.Huber, losses.CategoricalCrossEntropy} {
+		nn, err := NewNeuralNetwork(
+			InputLayerProps{NumInputs: 3},
+			[]HiddenLayerProps{HiddenLayerProps{NumNeurons: 4, ActFunc: actfuncs.Tanh}},
+			OutputLayerProps{NumOutputs: 2, ActFunc: actfuncs.Softmax},
+			WithSeed(1),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		report, err := GradCheck(nn, inputs, targets, lossFunc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.MaxRelativeError > 1e-5 {
+			t.Error("For GradCheck with softmax and", lossFunc, "Expected a relative error under", 1e-5, "Got", report.MaxRelativeError)
+		}
+	}
+}
+
+func TestGradCheckCustomActFunc(t *testing.T) {
+	//softsign registered with its derivative, and again with a derivative that is wrong
+	softsign := func(x float64) float64 { return x / (1 + math.Abs(x)) }
+	err := actfuncs.Register("gradCheckSoftsign", softsign, func(x float64) float64 {
+		return 1 / ((1 + math.Abs(x)) * (1 + math.Abs(x)))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = actfuncs.Register("gradCheckWrongSoftsign", softsign, func(x float64) float64 { return 1 / (1 + math.Abs(x)) })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inputs := []float64{0.4, -0.2, 0.7}
+	targets := []float64{1, 0}
+	report, err := GradCheck(newGradCheckTestNeuralNetwork(t, "gradCheckSoftsign"), inputs, targets, losses.BinaryCrossEntropy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.MaxRelativeError > 1e-5 {
+		t.Error("For the correct derivative", "Expected a relative error under", 1e-5, "Got", report.MaxRelativeError)
+	}
+
+	report, err = GradCheck(newGradCheckTestNeuralNetwork(t, "gradCheckWrongSoftsign"), inputs, targets, losses.BinaryCrossEntropy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	//the output layer is before the wrong derivative in backpropagation, every layer after it is not
+	if report.Layers[3].MaxRelativeError > 1e-5 {
+		t.Error("For the output layer", "Expected a relative error under", 1e-5, "Got", report.Layers[3].MaxRelativeError)
+	}
+	for _, neuronError := range report.Layers[2].Neurons {
+		if neuronError < 0.01 {
+			t.Error("For every neuron of the last hidden layer with the wrong derivative", "Expected a relative error over", 0.01, "Got", report.Layers[2].Neurons)
+			break
+		}
+	}
+	maxRelativeError := 0.0
+	for _, layer := range report.Layers {
+		maxRelativeError = math.Max(maxRelativeError, layer.MaxRelativeError)
+	}
+	if report.MaxRelativeError != maxRelativeError {
+		t.Error("For report.MaxRelativeError", "Expected", maxRelativeError, "Got", report.MaxRelativeError)
+	}
+}
+
+func TestGradCheckKeepsState(t *testing.T) {
+	nn, err := NewNeuralNetwork(
+		InputLayerProps{NumInputs: 3},
+		[]HiddenLayerProps{HiddenLayerProps{NumNeurons: 4, ActFunc: actfuncs.Tanh, Dropout: 0.5}},
+		OutputLayerProps{NumOutputs: 2, ActFunc: actfuncs.Sigmoid},
+		WithSeed(1),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nn.Mode = ModeTraining
+	setTestGradients(nn, 3, -4)
+
+	//dropout would make the loss random, so it is off while checking
+	report, err := GradCheck(nn, []float64{0.4, -0.2, 0.7}, []float64{1, 0}, losses.MSE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.MaxRelativeError > 1e-5 {
+		t.Error("For GradCheck with dropout", "Expected a relative error under", 1e-5, "Got", report.MaxRelativeError)
+	}
+	if nn.Mode != ModeTraining || nn.GradientNorm() != 5 {
+		t.Error("For the Mode and gradients after GradCheck", "Expected", ModeTraining, 5, "Got", nn.Mode, nn.GradientNorm())
+	}
+
+	_, err = GradCheck(nn, []float64{0.4}, []float64{1, 0}, losses.MSE)
+	if err == nil {
+		t.Error("For the wrong number of inputs, did not recieve error")
+	}
+	_, err = GradCheck(nn, []float64{0.4, -0.2, 0.7}, []float64{1}, losses.MSE)
+	if err == nil {
+		t.Error("For the wrong number of targets, did not recieve error")
+	}
+	_, err = GradCheck(nn, []float64{0.4, -0.2, 0.7}, []float64{1, 0}, "invalid")
+	if err == nil {
+		t.Error("For an unknown loss function, did not recieve error")
+	}
+}
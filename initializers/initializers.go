@@ -0,0 +1,79 @@
+/*
+Package initializers defines how the weights of a layer are set when a neural network is created.
+
+author: Jonas Yakimischak
+*/
+package initializers
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Initializer returns the initial value for one weight of a layer with fanIn inputs and fanOut neurons, drawing any
+// random numbers it needs from rng.  Any function with this signature can be used as a custom initializer.
+type Initializer func(rng *rand.Rand, fanIn int, fanOut int) float64
+
+//XavierUniform Xavier/Glorot uniform initializer, uniform in [-limit, limit) where limit = sqrt(6 / (fanIn + fanOut))
+var XavierUniform Initializer = func(rng *rand.Rand, fanIn int, fanOut int) float64 {
+	return uniform(rng, math.Sqrt(6/float64(fanIn+fanOut)))
+}
+
+//XavierNormal Xavier/Glorot normal initializer, normal with mean 0 and standard deviation sqrt(2 / (fanIn + fanOut))
+var XavierNormal Initializer = func(rng *rand.Rand, fanIn int, fanOut int) float64 {
+	return rng.NormFloat64() * math.Sqrt(2/float64(fanIn+fanOut))
+}
+
+//HeUniform He uniform initializer, uniform in [-limit, limit) where limit = sqrt(6 / fanIn)
+var HeUniform Initializer = func(rng *rand.Rand, fanIn int, fanOut int) float64 {
+	return uniform(rng, math.Sqrt(6/float64(fanIn)))
+}
+
+//HeNormal He normal initializer, normal with mean 0 and standard deviation sqrt(2 / fanIn)
+var HeNormal Initializer = func(rng *rand.Rand, fanIn int, fanOut int) float64 {
+	return rng.NormFloat64() * math.Sqrt(2/float64(fanIn))
+}
+
+//LeCunUniform LeCun uniform initializer, uniform in [-limit, limit) where limit = sqrt(3 / fanIn)
+var LeCunUniform Initializer = func(rng *rand.Rand, fanIn int, fanOut int) float64 {
+	return uniform(rng, math.Sqrt(3/float64(fanIn)))
+}
+
+//LeCunNormal LeCun normal initializer, normal with mean 0 and standard deviation sqrt(1 / fanIn)
+var LeCunNormal Initializer = func(rng *rand.Rand, fanIn int, fanOut int) float64 {
+	return rng.NormFloat64() * math.Sqrt(1/float64(fanIn))
+}
+
+//Zeros initializer, every weight is 0
+var Zeros = Constant(0)
+
+//Default initializer used when none is given, uniform in [0, 1) as weights have always been initialized
+var Default = Uniform(0, 1)
+
+// Constant will return an initializer that sets every weight to value.
+func Constant(value float64) Initializer {
+	return func(rng *rand.Rand, fanIn int, fanOut int) float64 {
+		return value
+	}
+}
+
+// Uniform will return an initializer that draws every weight uniformly from [min, max), whatever the fan-in and
+// fan-out are.
+func Uniform(min float64, max float64) Initializer {
+	return func(rng *rand.Rand, fanIn int, fanOut int) float64 {
+		return min + (max-min)*rng.Float64()
+	}
+}
+
+// Normal will return an initializer that draws every weight from a normal distribution, whatever the fan-in and
+// fan-out are.
+func Normal(mean float64, stdDev float64) Initializer {
+	return func(rng *rand.Rand, fanIn int, fanOut int) float64 {
+		return mean + stdDev*rng.NormFloat64()
+	}
+}
+
+// uniform will return a value drawn uniformly from [-limit, limit).
+func uniform(rng *rand.Rand, limit float64) float64 {
+	return (rng.Float64()*2 - 1) * limit
+}
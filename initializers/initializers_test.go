@@ -0,0 +1,100 @@
+package initializers
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// sampleStats will draw n values from init and return their mean, variance, min and max.
+func sampleStats(init Initializer, fanIn int, fanOut int, n int) (float64, float64, float64, float64) {
+	rng := rand.New(rand.NewSource(1))
+	sum := 0.0
+	sumSq := 0.0
+	min := math.Inf(1)
+	max := math.Inf(-1)
+	for i := 0; i < n; i++ {
+		v := init(rng, fanIn, fanOut)
+		sum += v
+		sumSq += v * v
+		min = math.Min(min, v)
+		max = math.Max(max, v)
+	}
+	mean := sum / float64(n)
+	return mean, sumSq/float64(n) - mean*mean, min, max
+}
+
+func TestInitializers(t *testing.T) {
+	const fanIn, fanOut, n = 30, 20, 100000
+
+	//uniform in [-limit, limit) has variance limit^2 / 3
+	uniforms := []struct {
+		name  string
+		init  Initializer
+		limit float64
+	}{
+		{"XavierUniform", XavierUniform, math.Sqrt(6.0 / (fanIn + fanOut))},
+		{"HeUniform", HeUniform, math.Sqrt(6.0 / fanIn)},
+		{"LeCunUniform", LeCunUniform, math.Sqrt(3.0 / fanIn)},
+	}
+	for _, u := range uniforms {
+		mean, variance, min, max := sampleStats(u.init, fanIn, fanOut, n)
+		if min < -u.limit || max >= u.limit {
+			t.Error("For", u.name, "Expected values in", -u.limit, u.limit, "Got", min, max)
+		}
+		if math.Abs(mean) > 0.01 {
+			t.Error("For", u.name, "mean", "Expected", 0, "Got", mean)
+		}
+		expectedVariance := u.limit * u.limit / 3
+		if math.Abs(variance-expectedVariance)/expectedVariance > 0.03 {
+			t.Error("For", u.name, "variance", "Expected", expectedVariance, "Got", variance)
+		}
+	}
+
+	normals := []struct {
+		name     string
+		init     Initializer
+		variance float64
+	}{
+		{"XavierNormal", XavierNormal, 2.0 / (fanIn + fanOut)},
+		{"HeNormal", HeNormal, 2.0 / fanIn},
+		{"LeCunNormal", LeCunNormal, 1.0 / fanIn},
+		{"Normal", Normal(0, 0.5), 0.25},
+	}
+	for _, nd := range normals {
+		mean, variance, _, _ := sampleStats(nd.init, fanIn, fanOut, n)
+		if math.Abs(mean) > 0.01 {
+			t.Error("For", nd.name, "mean", "Expected", 0, "Got", mean)
+		}
+		if math.Abs(variance-nd.variance)/nd.variance > 0.03 {
+			t.Error("For", nd.name, "variance", "Expected", nd.variance, "Got", variance)
+		}
+	}
+
+	//the scale must follow the fan-in and fan-out
+	_, narrow, _, _ := sampleStats(HeNormal, 10, fanOut, n)
+	_, wide, _, _ := sampleStats(HeNormal, 1000, fanOut, n)
+	if narrow <= wide {
+		t.Error("For HeNormal variance", "Expected fan-in 10 to be larger than fan-in 1000", "Got", narrow, wide)
+	}
+
+	mean, variance, min, max := sampleStats(Uniform(2, 3), fanIn, fanOut, n)
+	if min < 2 || max >= 3 || math.Abs(mean-2.5) > 0.01 {
+		t.Error("For Uniform(2, 3)", "Expected values in [2, 3) with mean 2.5", "Got", min, max, mean)
+	}
+	_, _, min, max = sampleStats(Zeros, fanIn, fanOut, 10)
+	if min != 0 || max != 0 {
+		t.Error("For Zeros", "Expected", 0, "Got", min, max)
+	}
+	_, variance, min, _ = sampleStats(Constant(0.25), fanIn, fanOut, 10)
+	if min != 0.25 || variance != 0 {
+		t.Error("For Constant(0.25)", "Expected", 0.25, "Got", min)
+	}
+
+	//Default matches how weights were always initialized
+	rng1 := rand.New(rand.NewSource(5))
+	rng2 := rand.New(rand.NewSource(5))
+	if Default(rng1, fanIn, fanOut) != rng2.Float64() {
+		t.Error("For Default", "Expected the same value as rand.Float64")
+	}
+}
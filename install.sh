@@ -1,4 +1,8 @@
 go install github.com/jyakimischak/neuralnet/actfuncs
+go install github.com/jyakimischak/neuralnet/initializers
+go install github.com/jyakimischak/neuralnet/losses
+go install github.com/jyakimischak/neuralnet/optimizers
+go install github.com/jyakimischak/neuralnet/schedules
 go install github.com/jyakimischak/neuralnet
 
 
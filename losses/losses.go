@@ -0,0 +1,266 @@
+/*
+Package losses defines the loss functions that score the outputs of a neural network against targets.
+
+author: Jonas Yakimischak
+*/
+package losses
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+)
+
+//MSE mean squared error loss
+const MSE = "mse"
+
+//MAE mean absolute error loss
+const MAE = "mae"
+
+//Huber loss, quadratic for errors up to DefaultHuberDelta and linear beyond.  Use NewHuber for another delta.
+const Huber = "huber"
+
+//BinaryCrossEntropy binary cross-entropy loss, every output is a probability and every target is 0 or 1
+const BinaryCrossEntropy = "binaryCrossEntropy"
+
+//CategoricalCrossEntropy categorical cross-entropy loss, the outputs are class probabilities and the targets are one-hot
+//or a probability distribution.  Unlike the other losses it is summed over the outputs rather than averaged.
+const CategoricalCrossEntropy = "categoricalCrossEntropy"
+
+//Hinge loss, every target is -1 or 1
+const Hinge = "hinge"
+
+//DefaultHuberDelta the error at which Huber changes from quadratic to linear
+const DefaultHuberDelta = 1.0
+
+// epsilon keeps the cross-entropy losses away from log(0) and division by 0.
+const epsilon = 1e-12
+
+// Func calculates the value of a loss for outputs and targets of the same length.
+type Func func(outputs []float64, targets []float64) float64
+
+// GradFunc calculates the gradient of a loss with respect to each output and writes it to grads, which is the same
+// length as outputs and targets.
+type GradFunc func(outputs []float64, targets []float64, grads []float64)
+
+// loss is a registered loss function and its gradient.
+type loss struct {
+	value    Func
+	gradient GradFunc
+}
+
+// registry holds every loss function by name.  It is guarded by registryMu so Register can be called from concurrent
+// init code.
+var registryMu sync.RWMutex
+var registry = map[string]loss{
+	MSE:                     {value: calcMSE, gradient: calcMSEGradient},
+	MAE:                     {value: calcMAE, gradient: calcMAEGradient},
+	Huber:                   huber(DefaultHuberDelta),
+	BinaryCrossEntropy:      {value: calcBinaryCrossEntropy, gradient: calcBinaryCrossEntropyGradient},
+	CategoricalCrossEntropy: {value: calcCategoricalCrossEntropy, gradient: calcCategoricalCrossEntropyGradient},
+	Hinge:                   {value: calcHinge, gradient: calcHingeGradient},
+}
+
+// Register will add a new loss function under the given name so it can be used anywhere a built in loss function
+// can.  An error is returned if the name is empty or already registered, or if either function is nil.
+func Register(name string, value Func, gradient GradFunc) error {
+	if name == "" {
+		return errors.New("Register: name must not be empty")
+	}
+	if value == nil || gradient == nil {
+		return fmt.Errorf("Register: value and gradient must not be nil for loss function: %s", name)
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, exists := registry[name]; exists {
+		return fmt.Errorf("Register: loss function is already registered: %s", name)
+	}
+	registry[name] = loss{value: value, gradient: gradient}
+	return nil
+}
+
+// Lookup will return the loss function and its gradient registered under the given name.
+func Lookup(name string) (value Func, gradient GradFunc, ok bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	l, ok := registry[name]
+	return l.value, l.gradient, ok
+}
+
+// NewHuber will return the Huber loss and its gradient with the given delta, the error at which the loss changes from
+// quadratic to linear, which must be > 0.  Register them under a name of their own to use them with a network, for
+// example
+//
+//	value, gradient := losses.NewHuber(0.5)
+//	err := losses.Register("huber0.5", value, gradient)
+func NewHuber(delta float64) (value Func, gradient GradFunc) {
+	l := huber(delta)
+	return l.value, l.gradient
+}
+
+// IsValidLoss will return true if the given string is a valid loss function.
+func IsValidLoss(lossFunc string) bool {
+	_, _, ok := Lookup(lossFunc)
+	return ok
+}
+
+// CalcLoss will return the value of the given loss function for the outputs and targets.  An error is returned if the
+// loss function is unknown or the lengths do not match.
+func CalcLoss(lossFunc string, outputs []float64, targets []float64) (float64, error) {
+	value, _, ok := Lookup(lossFunc)
+	if !ok {
+		return 0, fmt.Errorf("Unknown loss function: %s", lossFunc)
+	}
+	if len(outputs) != len(targets) {
+		return 0, fmt.Errorf("len(outputs) != len(targets): %d, %d", len(outputs), len(targets))
+	}
+	return value(outputs, targets), nil
+}
+
+// CalcGradient will return the gradient of the given loss function with respect to each output.  An error is
+// returned if the loss function is unknown or the lengths do not match.
+func CalcGradient(lossFunc string, outputs []float64, targets []float64) ([]float64, error) {
+	_, gradient, ok := Lookup(lossFunc)
+	if !ok {
+		return nil, fmt.Errorf("Unknown loss function: %s", lossFunc)
+	}
+	if len(outputs) != len(targets) {
+		return nil, fmt.Errorf("len(outputs) != len(targets): %d, %d", len(outputs), len(targets))
+	}
+	grads := make([]float64, len(outputs))
+	gradient(outputs, targets, grads)
+	return grads, nil
+}
+
+// calcMSE calculate mean squared error
+func calcMSE(outputs []float64, targets []float64) float64 {
+	sum := 0.0
+	for i := range outputs {
+		diff := outputs[i] - targets[i]
+		sum += diff * diff
+	}
+	return sum / float64(len(outputs))
+}
+
+// calcMSEGradient calculate the gradient of mean squared error
+func calcMSEGradient(outputs []float64, targets []float64, grads []float64) {
+	for i := range outputs {
+		grads[i] = 2 * (outputs[i] - targets[i]) / float64(len(outputs))
+	}
+}
+
+// calcMAE calculate mean absolute error
+func calcMAE(outputs []float64, targets []float64) float64 {
+	sum := 0.0
+	for i := range outputs {
+		sum += math.Abs(outputs[i] - targets[i])
+	}
+	return sum / float64(len(outputs))
+}
+
+// calcMAEGradient calculate the gradient of mean absolute error, taken as 0 where the output equals the target
+func calcMAEGradient(outputs []float64, targets []float64, grads []float64) {
+	for i := range outputs {
+		grads[i] = sign(outputs[i]-targets[i]) / float64(len(outputs))
+	}
+}
+
+// huber will return the Huber loss and its gradient with the given delta
+func huber(delta float64) loss {
+	return loss{
+		value: func(outputs []float64, targets []float64) float64 {
+			sum := 0.0
+			for i := range outputs {
+				diff := math.Abs(outputs[i] - targets[i])
+				if diff <= delta {
+					sum += 0.5 * diff * diff
+				} else {
+					sum += delta * (diff - 0.5*delta)
+				}
+			}
+			return sum / float64(len(outputs))
+		},
+		gradient: func(outputs []float64, targets []float64, grads []float64) {
+			for i := range outputs {
+				diff := outputs[i] - targets[i]
+				if math.Abs(diff) <= delta {
+					grads[i] = diff / float64(len(outputs))
+				} else {
+					grads[i] = delta * sign(diff) / float64(len(outputs))
+				}
+			}
+		},
+	}
+}
+
+// calcBinaryCrossEntropy calculate binary cross-entropy, the outputs are clipped to [epsilon, 1 - epsilon]
+func calcBinaryCrossEntropy(outputs []float64, targets []float64) float64 {
+	sum := 0.0
+	for i := range outputs {
+		o := clip(outputs[i])
+		sum -= targets[i]*math.Log(o) + (1-targets[i])*math.Log(1-o)
+	}
+	return sum / float64(len(outputs))
+}
+
+// calcBinaryCrossEntropyGradient calculate the gradient of binary cross-entropy
+func calcBinaryCrossEntropyGradient(outputs []float64, targets []float64, grads []float64) {
+	for i := range outputs {
+		o := clip(outputs[i])
+		grads[i] = (o - targets[i]) / (o * (1 - o)) / float64(len(outputs))
+	}
+}
+
+// calcCategoricalCrossEntropy calculate categorical cross-entropy, the outputs are clipped to at least epsilon
+func calcCategoricalCrossEntropy(outputs []float64, targets []float64) float64 {
+	sum := 0.0
+	for i := range outputs {
+		sum -= targets[i] * math.Log(math.Max(outputs[i], epsilon))
+	}
+	return sum
+}
+
+// calcCategoricalCrossEntropyGradient calculate the gradient of categorical cross-entropy
+func calcCategoricalCrossEntropyGradient(outputs []float64, targets []float64, grads []float64) {
+	for i := range outputs {
+		grads[i] = -targets[i] / math.Max(outputs[i], epsilon)
+	}
+}
+
+// calcHinge calculate hinge loss
+func calcHinge(outputs []float64, targets []float64) float64 {
+	sum := 0.0
+	for i := range outputs {
+		sum += math.Max(0, 1-targets[i]*outputs[i])
+	}
+	return sum / float64(len(outputs))
+}
+
+// calcHingeGradient calculate the gradient of hinge loss, taken as 0 at the hinge
+func calcHingeGradient(outputs []float64, targets []float64, grads []float64) {
+	for i := range outputs {
+		if 1-targets[i]*outputs[i] > 0 {
+			grads[i] = -targets[i] / float64(len(outputs))
+		} else {
+			grads[i] = 0
+		}
+	}
+}
+
+// sign will return -1, 0 or 1 for the sign of x.
+func sign(x float64) float64 {
+	if x > 0 {
+		return 1
+	}
+	if x < 0 {
+		return -1
+	}
+	return 0
+}
+
+// clip will keep a probability within [epsilon, 1 - epsilon].
+func clip(p float64) float64 {
+	return math.Min(math.Max(p, epsilon), 1-epsilon)
+}
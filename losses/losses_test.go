@@ -0,0 +1,161 @@
+package losses
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCalcLoss(t *testing.T) {
+	outputs := []float64{0.5, 2, -1}
+	targets := []float64{1, 0, -1}
+
+	expected := map[string]float64{
+		MSE: (0.25 + 4 + 0) / 3,
+		MAE: (0.5 + 2 + 0) / 3,
+		//0.5 is quadratic, 2 is linear: 1 * (2 - 0.5)
+		Huber: (0.125 + 1.5 + 0) / 3,
+		//1 - t * o clipped at 0: 0.5, 1, 0
+		Hinge: (0.5 + 1 + 0) / 3,
+	}
+	for lossFunc, e := range expected {
+		got, err := CalcLoss(lossFunc, outputs, targets)
+		if err != nil {
+			t.Error(err)
+		}
+		if math.Abs(got-e) > 1e-12 {
+			t.Error("For", lossFunc, "Expected", e, "Got", got)
+		}
+	}
+
+	probs := []float64{0.8, 0.1, 0.1}
+	oneHot := []float64{1, 0, 0}
+	bce, _ := CalcLoss(BinaryCrossEntropy, probs, oneHot)
+	if math.Abs(bce-(-math.Log(0.8)-2*math.Log(0.9))/3) > 1e-12 {
+		t.Error("For BinaryCrossEntropy", "Expected", (-math.Log(0.8)-2*math.Log(0.9))/3, "Got", bce)
+	}
+	cce, _ := CalcLoss(CategoricalCrossEntropy, probs, oneHot)
+	if math.Abs(cce+math.Log(0.8)) > 1e-12 {
+		t.Error("For CategoricalCrossEntropy", "Expected", -math.Log(0.8), "Got", cce)
+	}
+
+	//outputs of exactly 0 or 1 must not give an infinite loss
+	bceEdge, _ := CalcLoss(BinaryCrossEntropy, []float64{0, 1}, []float64{1, 0})
+	cceEdge, _ := CalcLoss(CategoricalCrossEntropy, []float64{0, 1}, []float64{1, 0})
+	if math.IsInf(bceEdge, 0) || math.IsNaN(bceEdge) || math.IsInf(cceEdge, 0) || math.IsNaN(cceEdge) {
+		t.Error("For cross-entropy of 0 and 1 outputs", "Expected finite values", "Got", bceEdge, cceEdge)
+	}
+
+	_, err := CalcLoss("invalid", outputs, targets)
+	if err == nil {
+		t.Error("For unknown loss function, did not recieve error")
+	}
+	_, err = CalcLoss(MSE, outputs, targets[:2])
+	if err == nil {
+		t.Error("For mismatched lengths, did not recieve error")
+	}
+	_, err = CalcGradient(MSE, outputs, targets[:2])
+	if err == nil {
+		t.Error("For mismatched lengths, did not recieve error")
+	}
+}
+
+func TestCalcGradient(t *testing.T) {
+	//values are kept away from the kinks of MAE, Huber and Hinge
+	outputs := []float64{0.3, 0.6, 0.15}
+	targets := []float64{1, 0, 0.5}
+	hingeTargets := []float64{1, -1, 1}
+
+	lossFuncs := []string{MSE, MAE, Huber, BinaryCrossEntropy, CategoricalCrossEntropy, Hinge}
+	const h = 1e-6
+	for _, lossFunc := range lossFuncs {
+		if !IsValidLoss(lossFunc) {
+			t.Error("For IsValidLoss", lossFunc, "Expected", true, "Got", false)
+		}
+		tgts := targets
+		if lossFunc == Hinge {
+			tgts = hingeTargets
+		}
+		grads, err := CalcGradient(lossFunc, outputs, tgts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range outputs {
+			plus := append([]float64{}, outputs...)
+			minus := append([]float64{}, outputs...)
+			plus[i] += h
+			minus[i] -= h
+			lossPlus, _ := CalcLoss(lossFunc, plus, tgts)
+			lossMinus, _ := CalcLoss(lossFunc, minus, tgts)
+			numeric := (lossPlus - lossMinus) / (2 * h)
+			if math.Abs(numeric-grads[i]) > 1e-6 {
+				t.Errorf("For %s gradient [%d] Expected %g Got %g", lossFunc, i, numeric, grads[i])
+			}
+		}
+	}
+
+	//Huber is linear beyond DefaultHuberDelta
+	grads, _ := CalcGradient(Huber, []float64{5}, []float64{0})
+	if grads[0] != DefaultHuberDelta {
+		t.Error("For Huber gradient beyond delta", "Expected", DefaultHuberDelta, "Got", grads[0])
+	}
+}
+
+func TestNewHuber(t *testing.T) {
+	value, gradient := NewHuber(0.5)
+	grads := make([]float64, 2)
+	gradient([]float64{0.25, 3}, []float64{0, 0}, grads)
+	if grads[0] != 0.125 || grads[1] != 0.25 {
+		t.Error("For Huber with delta 0.5 gradients", "Expected", 0.125, 0.25, "Got", grads)
+	}
+	expected := (0.5*0.25*0.25 + 0.5*(3-0.25)) / 2
+	if got := value([]float64{0.25, 3}, []float64{0, 0}); math.Abs(got-expected) > 1e-12 {
+		t.Error("For Huber with delta 0.5", "Expected", expected, "Got", got)
+	}
+
+	//the built in Huber keeps the default delta
+	err := Register("huber0.5", value, gradient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := CalcLoss("huber0.5", []float64{3}, []float64{0})
+	def, _ := CalcLoss(Huber, []float64{3}, []float64{0})
+	if got != 0.5*(3-0.25) || def != 3-0.5 {
+		t.Error("For the registered and built in Huber", "Expected", 0.5*(3-0.25), 3-0.5, "Got", got, def)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	sumError := func(outputs []float64, targets []float64) float64 {
+		sum := 0.0
+		for i := range outputs {
+			sum += outputs[i] - targets[i]
+		}
+		return sum
+	}
+	sumErrorGradient := func(outputs []float64, targets []float64, grads []float64) {
+		for i := range grads {
+			grads[i] = 1
+		}
+	}
+
+	err := Register("testSumError", sumError, sumErrorGradient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, _ := CalcLoss("testSumError", []float64{3, 4}, []float64{1, 1})
+	if value != 5 {
+		t.Error("For testSumError", "Expected", 5, "Got", value)
+	}
+	if Register("testSumError", sumError, sumErrorGradient) == nil {
+		t.Error("For duplicate name, did not recieve error")
+	}
+	if Register(MSE, sumError, sumErrorGradient) == nil {
+		t.Error("For built in name, did not recieve error")
+	}
+	if Register("", sumError, sumErrorGradient) == nil {
+		t.Error("For empty name, did not recieve error")
+	}
+	if Register("testNil", nil, sumErrorGradient) == nil {
+		t.Error("For nil value, did not recieve error")
+	}
+}
@@ -0,0 +1,79 @@
+package neuralnet
+
+// The layers store their weights as dense row-major matrices, row i holding the weights of neuron i.  These are the
+// kernels that work on them.  Every kernel builds its sums with dot, so a value comes out exactly the same whether it
+// was calculated one sample at a time or as part of a batch.
+
+// dot will return the dot product of a and b, which must be the same length.
+func dot(a []float64, b []float64) float64 {
+	b = b[:len(a)]
+	var s0, s1, s2, s3 float64
+	i := 0
+	for ; i+4 <= len(a); i += 4 {
+		s0 += a[i] * b[i]
+		s1 += a[i+1] * b[i+1]
+		s2 += a[i+2] * b[i+2]
+		s3 += a[i+3] * b[i+3]
+	}
+	for ; i < len(a); i++ {
+		s0 += a[i] * b[i]
+	}
+	return (s0 + s1) + (s2 + s3)
+}
+
+// axpy will add alpha * x to y, which must be the same length.
+func axpy(alpha float64, x []float64, y []float64) {
+	y = y[:len(x)]
+	for i, v := range x {
+		y[i] += alpha * v
+	}
+}
+
+// matVec will set y to m * x, where m is a rows x cols matrix, x has cols values and y has rows values.
+func matVec(m []float64, rows int, cols int, x []float64, y []float64) {
+	for r := 0; r < rows; r++ {
+		y[r] = dot(m[r*cols:(r+1)*cols], x)
+	}
+}
+
+// matTransVecAdd will add m^T * x to y, where m is a rows x cols matrix, x has rows values and y has cols values.
+func matTransVecAdd(m []float64, rows int, cols int, x []float64, y []float64) {
+	for r := 0; r < rows; r++ {
+		axpy(x[r], m[r*cols:(r+1)*cols], y)
+	}
+}
+
+// matMulTransB will set c to a * b^T, where a is an aRows x cols matrix, b is a bRows x cols matrix and c is an
+// aRows x bRows matrix.  With a holding one sample per row and b holding a layer's weights this calculates the
+// weighted sums of every neuron for every sample.
+func matMulTransB(a []float64, aRows int, cols int, b []float64, bRows int, c []float64) {
+	for i := 0; i < aRows; i++ {
+		aRow := a[i*cols : (i+1)*cols]
+		cRow := c[i*bRows : (i+1)*bRows]
+		for j := 0; j < bRows; j++ {
+			cRow[j] = dot(aRow, b[j*cols:(j+1)*cols])
+		}
+	}
+}
+
+// matMulAdd will add a * b to c, where a is an aRows x inner matrix, b is an inner x bCols matrix and c is an
+// aRows x bCols matrix.
+func matMulAdd(a []float64, aRows int, inner int, b []float64, bCols int, c []float64) {
+	for i := 0; i < aRows; i++ {
+		cRow := c[i*bCols : (i+1)*bCols]
+		for k := 0; k < inner; k++ {
+			axpy(a[i*inner+k], b[k*bCols:(k+1)*bCols], cRow)
+		}
+	}
+}
+
+// matTransMulAdd will add a^T * b to c, where a is a rows x aCols matrix, b is a rows x bCols matrix and c is an
+// aCols x bCols matrix.  With a holding the deltas and b the inputs of a batch this accumulates the weight gradients.
+func matTransMulAdd(a []float64, rows int, aCols int, b []float64, bCols int, c []float64) {
+	for r := 0; r < rows; r++ {
+		bRow := b[r*bCols : (r+1)*bCols]
+		for i := 0; i < aCols; i++ {
+			axpy(a[r*aCols+i], bRow, c[i*bCols:(i+1)*bCols])
+		}
+	}
+}
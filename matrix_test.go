@@ -0,0 +1,165 @@
+package neuralnet
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// randomMatrix will return a rows x cols matrix of random values.
+func randomMatrix(rows int, cols int) []float64 {
+	m := make([]float64, rows*cols)
+	for i := range m {
+		m[i] = rand.Float64()*2 - 1
+	}
+	return m
+}
+
+// naiveMatMul will return a * b the textbook way, for checking the kernels.
+func naiveMatMul(a []float64, aRows int, inner int, b []float64, bCols int) []float64 {
+	c := make([]float64, aRows*bCols)
+	for i := 0; i < aRows; i++ {
+		for j := 0; j < bCols; j++ {
+			for k := 0; k < inner; k++ {
+				c[i*bCols+j] += a[i*inner+k] * b[k*bCols+j]
+			}
+		}
+	}
+	return c
+}
+
+// transpose will return the transpose of the rows x cols matrix m.
+func transpose(m []float64, rows int, cols int) []float64 {
+	t := make([]float64, len(m))
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			t[j*rows+i] = m[i*cols+j]
+		}
+	}
+	return t
+}
+
+// checkClose will report an error for every value in got that is not within 1e-12 of expected.
+func checkClose(t *testing.T, name string, expected []float64, got []float64) {
+	if len(expected) != len(got) {
+		t.Fatalf("For len(%s) Expected %d Got %d", name, len(expected), len(got))
+	}
+	for i := range expected {
+		if math.Abs(expected[i]-got[i]) > 1e-12 {
+			t.Errorf("For %s[%d] Expected %f Got %f", name, i, expected[i], got[i])
+		}
+	}
+}
+
+func TestMatrixKernels(t *testing.T) {
+	//7 columns so the unrolled dot product has a remainder
+	const rows, cols, batch = 5, 7, 3
+	m := randomMatrix(rows, cols)
+	x := randomMatrix(cols, 1)
+
+	y := make([]float64, rows)
+	matVec(m, rows, cols, x, y)
+	checkClose(t, "matVec", naiveMatMul(m, rows, cols, x, 1), y)
+
+	v := randomMatrix(rows, 1)
+	yt := []float64{1, 1, 1, 1, 1, 1, 1}
+	matTransVecAdd(m, rows, cols, v, yt)
+	expected := naiveMatMul(transpose(m, rows, cols), cols, rows, v, 1)
+	for i := range expected {
+		expected[i]++
+	}
+	checkClose(t, "matTransVecAdd", expected, yt)
+
+	a := randomMatrix(batch, cols)
+	c := make([]float64, batch*rows)
+	matMulTransB(a, batch, cols, m, rows, c)
+	checkClose(t, "matMulTransB", naiveMatMul(a, batch, cols, transpose(m, rows, cols), rows), c)
+	//each row of a batch must match the same row calculated on its own
+	for iRow := 0; iRow < batch; iRow++ {
+		matVec(m, rows, cols, a[iRow*cols:(iRow+1)*cols], y)
+		for j := 0; j < rows; j++ {
+			if y[j] != c[iRow*rows+j] {
+				t.Errorf("For matMulTransB row %d [%d] Expected exactly %f Got %f", iRow, j, y[j], c[iRow*rows+j])
+			}
+		}
+	}
+
+	d := randomMatrix(batch, rows)
+	cm := make([]float64, batch*cols)
+	matMulAdd(d, batch, rows, m, cols, cm)
+	checkClose(t, "matMulAdd", naiveMatMul(d, batch, rows, m, cols), cm)
+
+	g := make([]float64, rows*cols)
+	matTransMulAdd(d, batch, rows, a, cols, g)
+	checkClose(t, "matTransMulAdd", naiveMatMul(transpose(d, batch, rows), rows, batch, a, cols), g)
+}
+
+// referenceNeuron mirrors how a layer used to be stored, as one neuron per pointer with its own copy of the inputs, so
+// the benchmarks can compare it with the matrix kernels.
+type referenceNeuron struct {
+	Weights []float64
+	Inputs  []float64
+	Output  float64
+}
+
+const benchNumInputs = 256
+const benchNumNeurons = 256
+const benchBatch = 64
+
+func BenchmarkLayerReferenceNeurons(b *testing.B) {
+	neurons := make([]*referenceNeuron, benchNumNeurons)
+	for i := range neurons {
+		neurons[i] = &referenceNeuron{Weights: randomMatrix(benchNumInputs, 1), Inputs: make([]float64, benchNumInputs)}
+	}
+	inputs := randomMatrix(benchNumInputs, 1)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, n := range neurons {
+			copy(n.Inputs, inputs)
+			n.Output = 0
+			for j := range n.Inputs {
+				n.Output += n.Inputs[j] * n.Weights[j]
+			}
+		}
+	}
+}
+
+func BenchmarkLayerMatVec(b *testing.B) {
+	m := randomMatrix(benchNumNeurons, benchNumInputs)
+	inputs := randomMatrix(benchNumInputs, 1)
+	outputs := make([]float64, benchNumNeurons)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		matVec(m, benchNumNeurons, benchNumInputs, inputs, outputs)
+	}
+}
+
+func BenchmarkLayerBatchReferenceNeurons(b *testing.B) {
+	neurons := make([]*referenceNeuron, benchNumNeurons)
+	for i := range neurons {
+		neurons[i] = &referenceNeuron{Weights: randomMatrix(benchNumInputs, 1), Inputs: make([]float64, benchNumInputs)}
+	}
+	inputs := randomMatrix(benchBatch, benchNumInputs)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for iRow := 0; iRow < benchBatch; iRow++ {
+			for _, n := range neurons {
+				copy(n.Inputs, inputs[iRow*benchNumInputs:(iRow+1)*benchNumInputs])
+				n.Output = 0
+				for j := range n.Inputs {
+					n.Output += n.Inputs[j] * n.Weights[j]
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkLayerBatchMatMul(b *testing.B) {
+	m := randomMatrix(benchNumNeurons, benchNumInputs)
+	inputs := randomMatrix(benchBatch, benchNumInputs)
+	outputs := make([]float64, benchBatch*benchNumNeurons)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		matMulTransB(inputs, benchBatch, benchNumInputs, m, benchNumNeurons, outputs)
+	}
+}
@@ -8,105 +8,85 @@ package neuralnet
 import (
 	"errors"
 	"fmt"
+	"math"
 	"math/rand"
-	"time"
+	"sync"
 
 	"github.com/jyakimischak/neuralnet/actfuncs"
+	"github.com/jyakimischak/neuralnet/initializers"
 )
 
-const initialBias = 0
-
 const layerTypeInput = "layerTypeInput"
 const layerTypeHidden = "layerTypeHidden"
 const layerTypeOutput = "layerTypeOutput"
 
 const maxRecurseDepth = 30
 
-//*************************************************************************************************************
-//neuron
-
-// neuron is a single neuron in a layer.
-type neuron struct {
-	Weights      []float64
-	Inputs       []float64
-	Output       float64
-	OutBeforeAct float64
-	NumInputs    int
-	Bias         float64
-	ActFunc      string
-}
-
-// newNeuron will setup a neuron and return the instance of it.
-func newNeuron(numInputs int, actFunc string) (*neuron, error) {
-	rand.Seed(time.Now().UTC().UnixNano())
-
-	n := new(neuron)
-	if numInputs < 1 {
-		return n, errors.New("initNewNeuron: numInputs must be a positive integer")
-	}
-
-	n.NumInputs = numInputs
-
-	//numInputs+1 so that the bias is included
-	for i := 0; i < numInputs+1; i++ {
-		//random init values
-		n.Weights = append(n.Weights, rand.Float64())
-		//placeholders
-		n.Inputs = append(n.Inputs, 0)
-	}
-	n.Bias = initialBias
-
-	n.ActFunc = actFunc
-
-	return n, nil
-}
-
-// isNeuronValid will check if a neuron is in a valid state.
-func (n *neuron) isValid() (bool, string) {
-	if n.NumInputs < 1 {
-		return false, fmt.Sprintf("Invalid neuron. NumInput must be a positive integer but is %d. Did you call newNeuron when getting the instance?", n.NumInputs)
-	}
-	if len(n.Weights) != n.NumInputs+1 || len(n.Inputs) != n.NumInputs+1 {
-		return false, fmt.Sprintf("Invalid neuron. Weights/Input not initialized properly. Did you call newNeuron when getting the instance?")
-	}
-	return true, ""
-}
-
-// calc will calculate the output value for the neuron using the given activation function.
-func (n *neuron) calc() error {
-	isValid, isValidMsg := n.isValid()
-	if !isValid {
-		return errors.New(isValidMsg)
-	}
-
-	n.OutBeforeAct = 0
-	for i := 0; i <= n.NumInputs; i++ {
-		if i == n.NumInputs {
-			n.OutBeforeAct += n.Bias
-		} else {
-			n.OutBeforeAct += n.Inputs[i] * n.Weights[i]
-		}
-	}
+//ModeInference Calc runs the network as it is used for predictions, this is the default
+const ModeInference = "inference"
 
-	n.Output = actfuncs.ApplyActFunc(n.ActFunc, n.OutBeforeAct)
-
-	return nil
-}
+//ModeTraining Calc runs the network as it is trained, dropout is applied.  Normalization is not affected, BatchNorm
+//still uses its running statistics since Calc only has one sample, see Fit
+const ModeTraining = "training"
 
 //*************************************************************************************************************
 //neuralLayer
 
 // NeuralLayer represents a layer in the neural network.
+//
+// The parameters of the layer's neurons are stored together so the layer can be calculated with dense matrix kernels.
+// Neuron i has the weights Weights[i*NumInputs : (i+1)*NumInputs] and the bias Biases[i].  The bias is a parameter
+// like any weight, it is initialized, trained and saved with the weights.  Code that set a neuron's Bias field should
+// set Biases[i] instead, or use NeuralNetwork.SetBias.
 type neuralLayer struct {
-	LayerType  string
+	LayerType string
+	//Kind is LayerKindDense or one of the normalization kinds, see normalization.go
+	Kind       string
 	NumNeurons int
-	Neurons    []*neuron
 	PrevLayer  *neuralLayer
 	NextLayer  *neuralLayer
-	Inputs     []float64
-	NumInputs  int
-	Outputs    []float64
-	ActFunc    string
+	//Inputs are read by calc, for the input layer they are the inputs of the network
+	Inputs    []float64
+	NumInputs int
+	//Outputs are written by calc, one per neuron
+	Outputs []float64
+	ActFunc string
+	//ActAlpha is the slope of LeakyReLU or the scale of ELU for negative x, 0 for the default.  The other activation
+	//functions do not use it.
+	ActAlpha float64
+	//Weights is a NumNeurons x NumInputs row-major matrix, row i holds the weights of neuron i
+	Weights []float64
+	//Biases holds the bias of each neuron
+	Biases []float64
+	//OutBeforeAct is written by calc, the weighted sum plus bias of each neuron before the activation function
+	OutBeforeAct []float64
+	//WeightGrads and BiasGrads are the gradients accumulated by backward, the same shape as Weights and Biases
+	WeightGrads []float64
+	BiasGrads   []float64
+	//Deltas are written by backward, the gradient of the loss with respect to each neuron's OutBeforeAct
+	Deltas []float64
+	//L1 and L2 are the coefficients of the penalty L1 * sum(|w|) + L2 * sum(w^2) on the weights, the biases are only
+	//included when RegularizeBiases is true
+	L1               float64
+	L2               float64
+	RegularizeBiases bool
+	//Dropout is the fraction of the outputs dropped while training, DropoutMask is written by calc, 0 for a dropped
+	//output and 1 / (1 - Dropout) for a kept one, or all 1 outside of training
+	Dropout     float64
+	DropoutMask []float64
+	//Gamma and Beta are the scale and shift of a normalization layer, one of each per neuron, with their gradients.
+	//Normalization layers have no Weights or Biases.
+	Gamma      []float64
+	Beta       []float64
+	GammaGrads []float64
+	BetaGrads  []float64
+	//RunningMean and RunningVar are the statistics BatchNorm normalizes with outside of Fit
+	RunningMean []float64
+	RunningVar  []float64
+	//XHat and InvStd are written by calc for a normalization layer, the normalized inputs and 1 / the standard
+	//deviation they were normalized with, per neuron for BatchNorm and once for LayerNorm
+	XHat   []float64
+	InvStd []float64
 }
 
 // isValidLayerType will return true if the layer type is valid
@@ -114,9 +94,16 @@ func isValidLayerType(layerType string) bool {
 	return layerType == layerTypeHidden || layerType == layerTypeInput || layerType == layerTypeOutput
 }
 
+// isValidActAlpha will return true if alpha can be the alpha of an activation function.
+func isValidActAlpha(alpha float64) bool {
+	return alpha >= 0 && !math.IsInf(alpha, 1)
+}
+
 // newNeuralLayer will setup a neural layer and return an instance of it.
 // PrevLayer and NextLayer are NOT setup, they must be set after receiving the instance.
-func newNeuralLayer(layerType string, numNeurons int, numInputs int, actFunc string) (*neuralLayer, error) {
+// The weights are set by weightInit, or initializers.Default if it is nil, and the biases by biasInit, or
+// initializers.Zeros if it is nil, both drawing from rng.
+func newNeuralLayer(layerType string, numNeurons int, numInputs int, actFunc string, weightInit initializers.Initializer, biasInit initializers.Initializer, rng *rand.Rand) (*neuralLayer, error) {
 	nl := &neuralLayer{}
 
 	if !isValidLayerType(layerType) {
@@ -132,29 +119,43 @@ func newNeuralLayer(layerType string, numNeurons int, numInputs int, actFunc str
 		return nl, fmt.Errorf("Unknown activation function: %s", actFunc)
 	}
 
-	nl.LayerType = layerType
-	if layerType == layerTypeInput {
-		nl.ActFunc = actfuncs.NoActFunc
+	if weightInit == nil {
+		weightInit = initializers.Default
 	}
-
-	nl.NumNeurons = numNeurons
-	for i := 0; i < numNeurons; i++ {
-		n, err := newNeuron(numInputs, actFunc)
-		if err != nil {
-			return nl, err
-		}
-		nl.Neurons = append(nl.Neurons, n)
-		nl.Outputs = append(nl.Outputs, 0)
+	weights := make([]float64, numNeurons*numInputs)
+	for i := range weights {
+		weights[i] = weightInit(rng, numInputs, numNeurons)
 	}
-
-	nl.NumInputs = numInputs
-	for i := 0; i < numInputs; i++ {
-		nl.Inputs = append(nl.Inputs, 0)
+	if biasInit == nil {
+		biasInit = initializers.Zeros
+	}
+	biases := make([]float64, numNeurons)
+	for i := range biases {
+		biases[i] = biasInit(rng, numInputs, numNeurons)
 	}
 
-	nl.ActFunc = actFunc
+	return newDenseLayer(layerType, numNeurons, numInputs, actFunc, weights, biases), nil
+}
 
-	return nl, nil
+// newDenseLayer will return a dense layer that uses the given weights and biases, which must have
+// numNeurons * numInputs and numNeurons values.  The rest of the layer's slices are allocated for it.
+func newDenseLayer(layerType string, numNeurons int, numInputs int, actFunc string, weights []float64, biases []float64) *neuralLayer {
+	return &neuralLayer{
+		LayerType:    layerType,
+		Kind:         LayerKindDense,
+		NumNeurons:   numNeurons,
+		NumInputs:    numInputs,
+		ActFunc:      actFunc,
+		ActAlpha:     actfuncs.DefaultAlpha(actFunc),
+		Weights:      weights,
+		Biases:       biases,
+		Inputs:       make([]float64, numInputs),
+		Outputs:      make([]float64, numNeurons),
+		OutBeforeAct: make([]float64, numNeurons),
+		WeightGrads:  make([]float64, numNeurons*numInputs),
+		BiasGrads:    make([]float64, numNeurons),
+		Deltas:       make([]float64, numNeurons),
+	}
 }
 
 func (nl *neuralLayer) isValid() (bool, string) {
@@ -164,9 +165,6 @@ func (nl *neuralLayer) isValid() (bool, string) {
 	if nl.NumNeurons < 1 {
 		return false, fmt.Sprintf("NumNeurons must be > 0 but is: %d", nl.NumNeurons)
 	}
-	if len(nl.Neurons) != nl.NumNeurons {
-		return false, fmt.Sprintf("len(nl.Neurons) != nl.NumNeurons: %d, %d", len(nl.Neurons), nl.NumNeurons)
-	}
 	if len(nl.Outputs) != nl.NumNeurons {
 		return false, fmt.Sprintf("len(nl.Outputs) != nl.NumNeurons: %d, %d", len(nl.Outputs), nl.NumNeurons)
 	}
@@ -179,12 +177,29 @@ func (nl *neuralLayer) isValid() (bool, string) {
 	if !actfuncs.IsValidActFunc(nl.ActFunc) {
 		return false, fmt.Sprintf("Invalid activation function : %s", nl.ActFunc)
 	}
-
-	for iNeuron := 0; iNeuron < len(nl.Neurons); iNeuron++ {
-		isValid, validMsg := nl.Neurons[iNeuron].isValid()
-		if !isValid {
-			return false, validMsg
-		}
+	if !isValidActAlpha(nl.ActAlpha) {
+		return false, fmt.Sprintf("ActAlpha must be >= 0 but is: %f", nl.ActAlpha)
+	}
+	if nl.Dropout < 0 || nl.Dropout >= 1 {
+		return false, fmt.Sprintf("Dropout must be >= 0 and < 1 but is: %f", nl.Dropout)
+	}
+	if nl.Dropout > 0 && len(nl.DropoutMask) != nl.NumNeurons {
+		return false, fmt.Sprintf("len(nl.DropoutMask) != nl.NumNeurons: %d, %d", len(nl.DropoutMask), nl.NumNeurons)
+	}
+	if len(nl.Deltas) != nl.NumNeurons || len(nl.OutBeforeAct) != nl.NumNeurons {
+		return false, fmt.Sprintf("Invalid layer. OutBeforeAct/Deltas not initialized properly. Did you call newNeuralLayer when getting the instance?")
+	}
+	if isNormLayerKind(nl.Kind) {
+		return nl.isValidNorm()
+	}
+	if nl.Kind != LayerKindDense {
+		return false, fmt.Sprintf("Invalid layer kind: %s", nl.Kind)
+	}
+	if len(nl.Weights) != nl.NumNeurons*nl.NumInputs || len(nl.WeightGrads) != len(nl.Weights) {
+		return false, fmt.Sprintf("len(nl.Weights) and len(nl.WeightGrads) must be nl.NumNeurons * nl.NumInputs: %d, %d, %d", len(nl.Weights), len(nl.WeightGrads), nl.NumNeurons*nl.NumInputs)
+	}
+	if len(nl.Biases) != nl.NumNeurons || len(nl.BiasGrads) != nl.NumNeurons {
+		return false, fmt.Sprintf("Invalid layer. Biases/BiasGrads not initialized properly. Did you call newNeuralLayer when getting the instance?")
 	}
 
 	return true, ""
@@ -197,29 +212,100 @@ func (nl *neuralLayer) calc() error {
 		return errors.New(isValidMsg)
 	}
 
-	for iNeurons := 0; iNeurons < nl.NumNeurons; iNeurons++ {
-		//load the inputs into the neuron
-		for iInputs := 0; iInputs < nl.NumInputs; iInputs++ {
-			nl.Neurons[iNeurons].Inputs[iInputs] = nl.Inputs[iInputs]
-		}
-		err := nl.Neurons[iNeurons].calc()
-		if err != nil {
-			return err
-		}
-		nl.Outputs[iNeurons] = nl.Neurons[iNeurons].Output
+	if nl.Kind == LayerKindDense {
+		nl.weightedSums(nl.Inputs, 1, nl.OutBeforeAct)
+	} else {
+		nl.normalize(nl.Inputs, 1, false, nl.XHat, nl.InvStd, nl.OutBeforeAct)
 	}
+	nl.applyActFunc(nl.OutBeforeAct, 1, nl.Outputs)
 
 	return nil
 }
 
+// weightedSums will calculate the weighted sum plus bias of every neuron for numRows rows of inputs, stored one after
+// the other, and write them to outBeforeAct, also stored one row after the other.
+func (nl *neuralLayer) weightedSums(inputs []float64, numRows int, outBeforeAct []float64) {
+	if numRows == 1 {
+		matVec(nl.Weights, nl.NumNeurons, nl.NumInputs, inputs, outBeforeAct)
+	} else {
+		matMulTransB(inputs, numRows, nl.NumInputs, nl.Weights, nl.NumNeurons, outBeforeAct)
+	}
+	for iRow := 0; iRow < numRows; iRow++ {
+		row := outBeforeAct[iRow*nl.NumNeurons : (iRow+1)*nl.NumNeurons]
+		for iNeurons := range row {
+			row[iNeurons] += nl.Biases[iNeurons]
+		}
+	}
+}
+
+// applyActFunc will apply the layer's activation function to numRows rows of outBeforeAct and write the results to
+// outputs, which may be outBeforeAct itself.  Softmax is applied across each row.
+func (nl *neuralLayer) applyActFunc(outBeforeAct []float64, numRows int, outputs []float64) {
+	if nl.ActFunc == actfuncs.Softmax {
+		for iRow := 0; iRow < numRows; iRow++ {
+			actfuncs.ApplySoftmax(outBeforeAct[iRow*nl.NumNeurons:(iRow+1)*nl.NumNeurons], outputs[iRow*nl.NumNeurons:(iRow+1)*nl.NumNeurons])
+		}
+		return
+	}
+	forward, _, ok := actfuncs.LookupAlpha(nl.ActFunc, nl.ActAlpha)
+	if !ok {
+		copy(outputs, outBeforeAct[:numRows*nl.NumNeurons])
+		return
+	}
+	for i := 0; i < numRows*nl.NumNeurons; i++ {
+		outputs[i] = forward(outBeforeAct[i])
+	}
+}
+
 //*************************************************************************************************************
 //NeuralNetwork
 
 // NeuralNetwork is a pass forward neural network.
+//
+// Predict is the simplest way to run the network.  Calc works on the layers directly: it reads the values written to
+// InputLayer.Inputs and leaves the results in OutputLayer.Outputs, both of which are overwritten by the next call.
+//
+// The weights and biases are the network's parameters, everything else the layers hold is the state of the last
+// Calc, which Backward uses for training.  Calc and training are not safe for concurrent use.  Predict,
+// PredictBatch and InferenceContext keep their own state and only read the parameters, so any number of goroutines can
+// use them at once while the network is not being trained.
 type NeuralNetwork struct {
-	InputLayer   *neuralLayer
+	//InputLayer is the first layer, its Inputs are the inputs of the network
+	InputLayer *neuralLayer
+	//HiddenLayers are the layers between the input and output layers, in order
 	HiddenLayers []*neuralLayer
-	OutputLayer  *neuralLayer
+	//OutputLayer is the last layer, its Outputs are the outputs of the network
+	OutputLayer *neuralLayer
+	//BatchWorkers is the number of goroutines PredictBatch uses, if it is < 1 then runtime.GOMAXPROCS(0) is used
+	BatchWorkers int
+	//Mode is ModeTraining or ModeInference, it decides how Calc runs the network.  Predict, PredictBatch and
+	//InferenceContext always run in inference mode and Fit always trains in training mode.  The Mode only decides
+	//dropout, BatchNorm layers normalize with their running statistics in either mode and only Fit normalizes with the
+	//statistics of a batch and updates the running statistics.
+	Mode string
+
+	contextPool sync.Pool
+	rng         *rand.Rand
+}
+
+// Option configures a NeuralNetwork when calling NewNeuralNetwork.
+type Option func(nn *NeuralNetwork)
+
+// WithSeed will seed the random numbers the network uses, so the same seed and props always give the same network.
+func WithSeed(seed int64) Option {
+	return func(nn *NeuralNetwork) {
+		nn.rng = rand.New(rand.NewSource(seed))
+	}
+}
+
+// WithRand will make the network draw its random numbers from rng.  The network keeps using rng after it is created,
+// so rng must not be used by another goroutine at the same time.  A nil rng is ignored.
+func WithRand(rng *rand.Rand) Option {
+	return func(nn *NeuralNetwork) {
+		if rng != nil {
+			nn.rng = rng
+		}
+	}
 }
 
 // InputLayerProps is used when calling NewNeuralNetwork.
@@ -229,31 +315,86 @@ type InputLayerProps struct {
 
 // HiddenLayerProps is used when calling NewNeuralNetwork.
 type HiddenLayerProps struct {
+	//Kind is LayerKindDense, the default when it is empty, LayerKindBatchNorm or LayerKindLayerNorm.  A normalization
+	//layer has one neuron for each output of the layer before it, so NumNeurons can be left at 0, and ignores
+	//WeightInit, BiasInit, L1 and L2.  Its ActFunc is applied after the scale and shift.
+	Kind       string
 	NumNeurons int
 	ActFunc    string
+	//ActAlpha is the slope of LeakyReLU or the scale of ELU for negative x, if it is 0 then
+	//actfuncs.DefaultLeakyReLUSlope or actfuncs.DefaultELUAlpha is used
+	ActAlpha float64
+	//WeightInit sets the initial weights, if it is nil then initializers.Default is used
+	WeightInit initializers.Initializer
+	//BiasInit sets the initial biases, if it is nil then initializers.Zeros is used
+	BiasInit initializers.Initializer
+	//L1 and L2 add the penalty L1 * sum(|w|) + L2 * sum(w^2) over the layer's weights to the loss when training, the
+	//biases are only included when RegularizeBiases is true
+	L1               float64
+	L2               float64
+	RegularizeBiases bool
+	//Dropout is the fraction of the layer's outputs, from 0 up to but not including 1, that are dropped at random
+	//while training.  The outputs that are kept are scaled by 1 / (1 - Dropout) so nothing changes for inference.
+	Dropout float64
 }
 
 // OutputLayerProps is used when calling NewNeuralNetwork.
 type OutputLayerProps struct {
 	NumOutputs int
 	ActFunc    string
+	//ActAlpha is the slope of LeakyReLU or the scale of ELU for negative x, if it is 0 then
+	//actfuncs.DefaultLeakyReLUSlope or actfuncs.DefaultELUAlpha is used
+	ActAlpha float64
+	//WeightInit sets the initial weights, if it is nil then initializers.Default is used
+	WeightInit initializers.Initializer
+	//BiasInit sets the initial biases, if it is nil then initializers.Zeros is used
+	BiasInit initializers.Initializer
+	//L1 and L2 add the penalty L1 * sum(|w|) + L2 * sum(w^2) over the layer's weights to the loss when training, the
+	//biases are only included when RegularizeBiases is true
+	L1               float64
+	L2               float64
+	RegularizeBiases bool
 }
 
 // NewNeuralNetwork get an instance of a netral network.
-func NewNeuralNetwork(inputLayerProps InputLayerProps, hiddenLayerProps []HiddenLayerProps, outputLayerProps OutputLayerProps) (*NeuralNetwork, error) {
-	nn := &NeuralNetwork{}
+// Without WithSeed or WithRand every network gets its own randomly seeded source.
+func NewNeuralNetwork(inputLayerProps InputLayerProps, hiddenLayerProps []HiddenLayerProps, outputLayerProps OutputLayerProps, options ...Option) (*NeuralNetwork, error) {
+	nn := newEmptyNeuralNetwork(options)
 
 	//validate
 	if inputLayerProps.NumInputs < 1 {
 		return nn, fmt.Errorf("inputLayerProps.NumInputs must be > 0 and is: %d", inputLayerProps.NumInputs)
 	}
+	prevNumNeurons := inputLayerProps.NumInputs
 	for iHiddenLayer := 0; iHiddenLayer < len(hiddenLayerProps); iHiddenLayer++ {
-		if hiddenLayerProps[iHiddenLayer].NumNeurons < 1 {
-			return nn, fmt.Errorf("hiddenLayerProps[%d].NumNeurons must be > 0 and is: %d", iHiddenLayer, hiddenLayerProps[iHiddenLayer].NumNeurons)
+		if !isValidLayerKind(hiddenLayerProps[iHiddenLayer].Kind) {
+			return nn, fmt.Errorf("hiddenLayerProps[%d].Kind is unknown: %s", iHiddenLayer, hiddenLayerProps[iHiddenLayer].Kind)
+		}
+		if isNormLayerKind(hiddenLayerProps[iHiddenLayer].Kind) {
+			if hiddenLayerProps[iHiddenLayer].NumNeurons != 0 && hiddenLayerProps[iHiddenLayer].NumNeurons != prevNumNeurons {
+				return nn, fmt.Errorf("hiddenLayerProps[%d].NumNeurons must be 0 or %d for a %s layer and is: %d", iHiddenLayer, prevNumNeurons, hiddenLayerProps[iHiddenLayer].Kind, hiddenLayerProps[iHiddenLayer].NumNeurons)
+			}
+		} else {
+			if hiddenLayerProps[iHiddenLayer].NumNeurons < 1 {
+				return nn, fmt.Errorf("hiddenLayerProps[%d].NumNeurons must be > 0 and is: %d", iHiddenLayer, hiddenLayerProps[iHiddenLayer].NumNeurons)
+			}
+			prevNumNeurons = hiddenLayerProps[iHiddenLayer].NumNeurons
 		}
 		if !actfuncs.IsValidActFunc(hiddenLayerProps[iHiddenLayer].ActFunc) {
 			return nn, fmt.Errorf("hiddenLayerProps[%d].ActFunc is unknown: %s", iHiddenLayer, hiddenLayerProps[iHiddenLayer].ActFunc)
 		}
+		if hiddenLayerProps[iHiddenLayer].ActFunc == actfuncs.Softmax {
+			return nn, fmt.Errorf("hiddenLayerProps[%d].ActFunc can not be %s, it is only supported on the output layer", iHiddenLayer, actfuncs.Softmax)
+		}
+		if !isValidActAlpha(hiddenLayerProps[iHiddenLayer].ActAlpha) {
+			return nn, fmt.Errorf("hiddenLayerProps[%d].ActAlpha must be >= 0 and is: %f", iHiddenLayer, hiddenLayerProps[iHiddenLayer].ActAlpha)
+		}
+		if hiddenLayerProps[iHiddenLayer].L1 < 0 || hiddenLayerProps[iHiddenLayer].L2 < 0 {
+			return nn, fmt.Errorf("hiddenLayerProps[%d].L1 and L2 must be >= 0 and are: %f, %f", iHiddenLayer, hiddenLayerProps[iHiddenLayer].L1, hiddenLayerProps[iHiddenLayer].L2)
+		}
+		if hiddenLayerProps[iHiddenLayer].Dropout < 0 || hiddenLayerProps[iHiddenLayer].Dropout >= 1 {
+			return nn, fmt.Errorf("hiddenLayerProps[%d].Dropout must be >= 0 and < 1 and is: %f", iHiddenLayer, hiddenLayerProps[iHiddenLayer].Dropout)
+		}
 	}
 	if outputLayerProps.NumOutputs < 1 {
 		return nn, fmt.Errorf("outputLayerProps.NumOutputs must be > 0 and is: %d", outputLayerProps.NumOutputs)
@@ -261,9 +402,15 @@ func NewNeuralNetwork(inputLayerProps InputLayerProps, hiddenLayerProps []Hidden
 	if !actfuncs.IsValidActFunc(outputLayerProps.ActFunc) {
 		return nn, fmt.Errorf("outputLayerProps.ActFunc is unknown: %s", outputLayerProps.ActFunc)
 	}
+	if !isValidActAlpha(outputLayerProps.ActAlpha) {
+		return nn, fmt.Errorf("outputLayerProps.ActAlpha must be >= 0 and is: %f", outputLayerProps.ActAlpha)
+	}
+	if outputLayerProps.L1 < 0 || outputLayerProps.L2 < 0 {
+		return nn, fmt.Errorf("outputLayerProps.L1 and L2 must be >= 0 and are: %f, %f", outputLayerProps.L1, outputLayerProps.L2)
+	}
 
 	//create the input layer
-	il, err := newNeuralLayer(layerTypeInput, inputLayerProps.NumInputs, inputLayerProps.NumInputs, actfuncs.NoActFunc)
+	il, err := newNeuralLayer(layerTypeInput, inputLayerProps.NumInputs, inputLayerProps.NumInputs, actfuncs.NoActFunc, nil, nil, nn.rng)
 	if err != nil {
 		return nn, err
 	}
@@ -271,49 +418,72 @@ func NewNeuralNetwork(inputLayerProps InputLayerProps, hiddenLayerProps []Hidden
 
 	//create the hidden layers
 	for iHiddenLayer := 0; iHiddenLayer < len(hiddenLayerProps); iHiddenLayer++ {
+		props := hiddenLayerProps[iHiddenLayer]
+		prevLayer := nn.InputLayer
+		if iHiddenLayer > 0 {
+			prevLayer = nn.HiddenLayers[iHiddenLayer-1]
+		}
 		var hl *neuralLayer
 		var err error
-		if iHiddenLayer == 0 {
-			hl, err = newNeuralLayer(layerTypeHidden, hiddenLayerProps[iHiddenLayer].NumNeurons, inputLayerProps.NumInputs, hiddenLayerProps[iHiddenLayer].ActFunc)
-			if err != nil {
-				return nn, err
-			}
-			nn.InputLayer.NextLayer = hl
-			hl.PrevLayer = nn.InputLayer
+		if isNormLayerKind(props.Kind) {
+			//a normalization layer has one neuron for each output of the layer before it
+			hl = newNormLayer(props.Kind, prevLayer.NumNeurons, props.ActFunc)
 		} else {
-			hl, err = newNeuralLayer(layerTypeHidden, hiddenLayerProps[iHiddenLayer].NumNeurons, hiddenLayerProps[iHiddenLayer-1].NumNeurons, hiddenLayerProps[iHiddenLayer].ActFunc)
+			hl, err = newNeuralLayer(layerTypeHidden, props.NumNeurons, prevLayer.NumNeurons, props.ActFunc, props.WeightInit, props.BiasInit, nn.rng)
 			if err != nil {
 				return nn, err
 			}
-			nn.HiddenLayers[iHiddenLayer-1].NextLayer = hl
-			hl.PrevLayer = nn.HiddenLayers[iHiddenLayer-1]
+		}
+		prevLayer.NextLayer = hl
+		hl.PrevLayer = prevLayer
+		if props.ActAlpha != 0 {
+			hl.ActAlpha = props.ActAlpha
+		}
+		hl.L1 = props.L1
+		hl.L2 = props.L2
+		hl.RegularizeBiases = props.RegularizeBiases
+		if props.Dropout > 0 {
+			hl.Dropout = props.Dropout
+			hl.DropoutMask = make([]float64, hl.NumNeurons)
 		}
 		nn.HiddenLayers = append(nn.HiddenLayers, hl)
 	}
 
 	//create the output layer
-	var ol *neuralLayer
-	var err2 error
-	if len(hiddenLayerProps) > 0 {
-		ol, err2 = newNeuralLayer(layerTypeOutput, outputLayerProps.NumOutputs, hiddenLayerProps[len(hiddenLayerProps)-1].NumNeurons, outputLayerProps.ActFunc)
-		if err2 != nil {
-			return nn, err2
-		}
-		nn.HiddenLayers[len(hiddenLayerProps)-1].NextLayer = ol
-		ol.PrevLayer = nn.HiddenLayers[len(hiddenLayerProps)-1]
-	} else {
-		ol, err2 = newNeuralLayer(layerTypeOutput, outputLayerProps.NumOutputs, inputLayerProps.NumInputs, outputLayerProps.ActFunc)
-		if err2 != nil {
-			return nn, err2
-		}
-		nn.InputLayer.NextLayer = ol
-		ol.PrevLayer = nn.InputLayer
+	prevLayer := nn.InputLayer
+	if len(nn.HiddenLayers) > 0 {
+		prevLayer = nn.HiddenLayers[len(nn.HiddenLayers)-1]
+	}
+	ol, err := newNeuralLayer(layerTypeOutput, outputLayerProps.NumOutputs, prevLayer.NumNeurons, outputLayerProps.ActFunc, outputLayerProps.WeightInit, outputLayerProps.BiasInit, nn.rng)
+	if err != nil {
+		return nn, err
+	}
+	prevLayer.NextLayer = ol
+	ol.PrevLayer = prevLayer
+	if outputLayerProps.ActAlpha != 0 {
+		ol.ActAlpha = outputLayerProps.ActAlpha
 	}
+	ol.L1 = outputLayerProps.L1
+	ol.L2 = outputLayerProps.L2
+	ol.RegularizeBiases = outputLayerProps.RegularizeBiases
 	nn.OutputLayer = ol
 
 	return nn, nil
 }
 
+// newEmptyNeuralNetwork will return a network without layers with the options applied.
+func newEmptyNeuralNetwork(options []Option) *NeuralNetwork {
+	nn := &NeuralNetwork{}
+	for _, option := range options {
+		option(nn)
+	}
+	if nn.rng == nil {
+		//the global source is randomly seeded and safe for concurrent use, so networks created together still differ
+		nn.rng = rand.New(rand.NewSource(rand.Int63()))
+	}
+	return nn
+}
+
 // IsValid checks if this neural network is in a valid state.
 func (nn *NeuralNetwork) IsValid() (bool, string) {
 	return nn.isValidRecurse(1, nil, nn.InputLayer)
@@ -331,8 +501,8 @@ func (nn *NeuralNetwork) isValidRecurse(depth int, prevLayer *neuralLayer, layer
 	if layer.NumInputs != len(layer.Inputs) {
 		return false, fmt.Sprintf("At depth %d, layer.NumInputs != len(layer.Inputs): %d, %d", depth, layer.NumInputs, len(layer.Inputs))
 	}
-	if layer.NumNeurons != len(layer.Neurons) {
-		return false, fmt.Sprintf("At depth %d, layer.NumNeurons != len(layer.Neurons): %d, %d", depth, layer.NumNeurons, len(layer.Neurons))
+	if layer.Kind == LayerKindDense && layer.NumNeurons != len(layer.Biases) {
+		return false, fmt.Sprintf("At depth %d, layer.NumNeurons != len(layer.Biases): %d, %d", depth, layer.NumNeurons, len(layer.Biases))
 	}
 	if prevLayer != layer.PrevLayer {
 		return false, fmt.Sprintf("At depth %d, prevLayer != layer.PrevLayer", depth)
@@ -367,6 +537,9 @@ func (nn *NeuralNetwork) calcRecurse(depth int, layer *neuralLayer) error {
 		return errors.New("Max recurse depth reached")
 	}
 	layer.calc()
+	if layer.Dropout > 0 {
+		nn.calcDropout(layer)
+	}
 	//if we hit the output layer we are done
 	if layer.LayerType == layerTypeOutput {
 		return nil
@@ -1,124 +1,41 @@
 package neuralnet
 
 import (
-	"errors"
+	"math"
+	"math/rand"
 	"testing"
 
 	"github.com/jyakimischak/neuralnet/actfuncs"
+	"github.com/jyakimischak/neuralnet/initializers"
 )
 
-func TestNewNeuron(t *testing.T) {
-	const nInputs = 10
-
-	n, err := newNeuron(nInputs, actfuncs.NoActFunc)
-	if err != nil {
-		t.Error("For numInput=", nInputs, ", error ", err)
-	}
-	if n.NumInputs != nInputs {
-		t.Error("For n.NumInputs", "Expected", nInputs, "Got", n.NumInputs)
-	}
-	if len(n.Weights) != nInputs+1 {
-		t.Error("For len(n.Weights)", "Expected", nInputs+1, "Got", len(n.Weights))
-	}
-	if len(n.Inputs) != nInputs+1 {
-		t.Error("For len(n.Inputs)", "Expected", nInputs+1, "Got", len(n.Inputs))
-	}
-
-	_, err2 := newNeuron(0, actfuncs.NoActFunc)
-	if err2 == nil {
-		t.Error("For numInput=0, did not recieve error")
-	}
-
-	_, err3 := newNeuron(-1, actfuncs.NoActFunc)
-	if err3 == nil {
-		t.Error("For numInput=-1, did not recieve error")
-	}
-}
-
-// getNeuronKnownState will give a neuron with a known state for testing purposes.
-//output before activation:
-//1 * 10 + 2 * 20 + 3 * 30 + 5 = 145
-func getNeuronKnownState() (*neuron, error) {
-	n, err := newNeuron(3, actfuncs.NoActFunc)
-	if err != nil {
-		return n, errors.New("Error while creating known state neuron")
-	}
-	//set the inputs, weights and bias to known values
-	n.Inputs[0] = 1
-	n.Inputs[1] = 2
-	n.Inputs[2] = 3
-	n.Weights[0] = 10
-	n.Weights[1] = 20
-	n.Weights[2] = 30
-	n.Bias = 5
-
-	return n, nil
-}
-
-func TestNeuronCalc(t *testing.T) {
-	nInvalid := neuron{}
-	err := nInvalid.calc()
-	if err == nil {
-		t.Error("For invalid neuron, did not recieve error")
-	}
-
-	n, err2 := getNeuronKnownState()
-	if err2 != nil {
-		t.Error(err2)
-	}
-
-	err3 := n.calc()
-	if err3 != nil {
-		t.Error("Error while calling calc on known state neuron")
-	}
-	if n.OutBeforeAct != 145 {
-		t.Error("For n.OutBeforeAct", "Expected", 145, "Got", n.OutBeforeAct)
-	}
-	if n.Output != 145 {
-		t.Error("For n.Output no activation function", "Expected", 145, "Got", n.Output)
-	}
-
-	n.ActFunc = actfuncs.Step
-	err4 := n.calc()
-	if err4 != nil {
-		t.Error("Error while calling calc on known state neuron for step activation function")
-	}
-	if n.Output != 1 {
-		t.Error("For n.Output Step", "Expected", 1, "Got", n.Output)
-	}
-
-	n.ActFunc = actfuncs.Sigmoid
-	err5 := n.calc()
-	if err5 != nil {
-		t.Error("Error while calling calc on known state neuron for sigmoid activation function")
-	}
-	if n.Output != 1 {
-		t.Error("For n.Output Step", "Expected", 1, "Got", n.Output)
-	}
+// testRand will return a seeded source of random numbers for creating layers in tests.
+func testRand() *rand.Rand {
+	return rand.New(rand.NewSource(1))
 }
 
 func TestNewNeuralLayer(t *testing.T) {
-	_, err := newNeuralLayer("invalid", 10, 5, actfuncs.NoActFunc)
+	_, err := newNeuralLayer("invalid", 10, 5, actfuncs.NoActFunc, nil, nil, testRand())
 	if err == nil {
 		t.Error("For invalid layer type, did not recieve error")
 	}
 
-	_, err2 := newNeuralLayer(layerTypeInput, 0, 5, actfuncs.NoActFunc)
+	_, err2 := newNeuralLayer(layerTypeInput, 0, 5, actfuncs.NoActFunc, nil, nil, testRand())
 	if err2 == nil {
 		t.Error("For num neutrons 0, did not recieve error")
 	}
 
-	_, err3 := newNeuralLayer(layerTypeInput, 10, 0, actfuncs.NoActFunc)
+	_, err3 := newNeuralLayer(layerTypeInput, 10, 0, actfuncs.NoActFunc, nil, nil, testRand())
 	if err3 == nil {
 		t.Error("For num inputs 0, did not recieve error")
 	}
 
-	_, err4 := newNeuralLayer(layerTypeInput, 10, 5, "invalid")
+	_, err4 := newNeuralLayer(layerTypeInput, 10, 5, "invalid", nil, nil, testRand())
 	if err4 == nil {
 		t.Error("For invalid activation function, did not recieve error")
 	}
 
-	nl, err5 := newNeuralLayer(layerTypeInput, 10, 5, actfuncs.NoActFunc)
+	nl, err5 := newNeuralLayer(layerTypeInput, 10, 5, actfuncs.NoActFunc, nil, nil, testRand())
 	if err5 != nil {
 		t.Error("For valid neural layer, recieved error")
 	}
@@ -128,8 +45,17 @@ func TestNewNeuralLayer(t *testing.T) {
 	if nl.NumNeurons != 10 {
 		t.Error("For nl.NumNeurons", "Expected", 10, "Got", nl.NumNeurons)
 	}
-	if len(nl.Neurons) != 10 {
-		t.Error("For len(nl.Neurons)", "Expected", 10, "Got", nl.Neurons)
+	if len(nl.Weights) != 10*5 {
+		t.Error("For len(nl.Weights)", "Expected", 10*5, "Got", len(nl.Weights))
+	}
+	if len(nl.WeightGrads) != 10*5 {
+		t.Error("For len(nl.WeightGrads)", "Expected", 10*5, "Got", len(nl.WeightGrads))
+	}
+	if len(nl.Biases) != 10 {
+		t.Error("For len(nl.Biases)", "Expected", 10, "Got", len(nl.Biases))
+	}
+	if len(nl.OutBeforeAct) != 10 {
+		t.Error("For len(nl.OutBeforeAct)", "Expected", 10, "Got", len(nl.OutBeforeAct))
 	}
 	if len(nl.Outputs) != 10 {
 		t.Error("For len(nl.Outputs)", "Expected", 10, "Got", nl.Outputs)
@@ -145,7 +71,7 @@ func TestNewNeuralLayer(t *testing.T) {
 	}
 
 	//need to check a non-input layer to ensure the activation function is set properly
-	nl2, err6 := newNeuralLayer(layerTypeHidden, 10, 5, actfuncs.Sigmoid)
+	nl2, err6 := newNeuralLayer(layerTypeHidden, 10, 5, actfuncs.Sigmoid, nil, nil, testRand())
 	if err6 != nil {
 		t.Error("For valid neural layer, recieved error")
 	}
@@ -156,17 +82,18 @@ func TestNewNeuralLayer(t *testing.T) {
 }
 
 // getKnownStateNeuralLayer will return a neural layer with known state.
-// This uses getKnownStateNeuron for its neurons.
+// Every neuron has the same weights and bias, so the output before activation of each is:
+//1 * 10 + 2 * 20 + 3 * 30 + 5 = 145
 func getKnownStateNeuralLayer() (*neuralLayer, error) {
-	nl, err := newNeuralLayer(layerTypeInput, 3, 3, actfuncs.NoActFunc)
+	nl, err := newNeuralLayer(layerTypeInput, 3, 3, actfuncs.NoActFunc, nil, nil, testRand())
 	if err != nil {
 		return nl, err
 	}
 	for iNeurons := 0; iNeurons < 3; iNeurons++ {
-		nl.Neurons[iNeurons], err = getNeuronKnownState()
-		if err != nil {
-			return nl, err
-		}
+		nl.Weights[iNeurons*3] = 10
+		nl.Weights[iNeurons*3+1] = 20
+		nl.Weights[iNeurons*3+2] = 30
+		nl.Biases[iNeurons] = 5
 	}
 	nl.Inputs[0] = 1
 	nl.Inputs[1] = 2
@@ -186,16 +113,51 @@ func TestNeuralLayerCalc(t *testing.T) {
 	if err2 != nil {
 		t.Error("Error getting known state neural layer")
 	}
+	nlMissingBias, _ := getKnownStateNeuralLayer()
+	nlMissingBias.Biases = nlMissingBias.Biases[:2]
+	if nlMissingBias.calc() == nil {
+		t.Error("For neuralLayer missing a bias, did not recieve error")
+	}
+
 	err3 := nl.calc()
 	if err3 != nil {
 		t.Error(err3)
 	}
-
 	for iInputs := 0; iInputs < 3; iInputs++ {
+		if nl.OutBeforeAct[iInputs] != 145 {
+			t.Errorf("For nl.OutBeforeAct[%d] Expected 145 Got %f", iInputs, nl.OutBeforeAct[iInputs])
+		}
 		if nl.Outputs[iInputs] != 145 {
 			t.Errorf("For nl.Outputs[%d] Expected 145 Got %f", iInputs, nl.Outputs[iInputs])
 		}
 	}
+
+	nl.ActFunc = actfuncs.Step
+	err4 := nl.calc()
+	if err4 != nil {
+		t.Error("Error while calling calc on known state layer for step activation function")
+	}
+	if nl.Outputs[0] != 1 {
+		t.Error("For nl.Outputs[0] Step", "Expected", 1, "Got", nl.Outputs[0])
+	}
+
+	nl.ActFunc = actfuncs.Sigmoid
+	err5 := nl.calc()
+	if err5 != nil {
+		t.Error("Error while calling calc on known state layer for sigmoid activation function")
+	}
+	if nl.Outputs[0] != 1 {
+		t.Error("For nl.Outputs[0] Sigmoid", "Expected", 1, "Got", nl.Outputs[0])
+	}
+
+	//the neurons are independent rows of the weight matrix
+	nl.ActFunc = actfuncs.NoActFunc
+	nl.Weights[3] = 0
+	nl.Biases[2] = 0
+	nl.calc()
+	if nl.Outputs[0] != 145 || nl.Outputs[1] != 135 || nl.Outputs[2] != 140 {
+		t.Error("For nl.Outputs", "Expected", []float64{145, 135, 140}, "Got", nl.Outputs)
+	}
 }
 
 func TestNewNeuralNetwork(t *testing.T) {
@@ -248,6 +210,41 @@ func TestNewNeuralNetwork(t *testing.T) {
 		t.Error("For OutputLayerProps{NumOutputs: 1, ActFunc: \"invalid\"}, did not recieve error")
 	}
 
+	_, err5d := NewNeuralNetwork(
+		InputLayerProps{NumInputs: 1},
+		[]HiddenLayerProps{
+			HiddenLayerProps{NumNeurons: 2, ActFunc: actfuncs.Softmax},
+		},
+		OutputLayerProps{NumOutputs: 2, ActFunc: actfuncs.Softmax},
+	)
+	if err5d == nil {
+		t.Error("For HiddenLayerProps{NumNeurons: 2, ActFunc: actfuncs.Softmax}, did not recieve error")
+	}
+
+	//registered activation functions are accepted
+	_, err5a := NewNeuralNetwork(
+		InputLayerProps{NumInputs: 1},
+		nil,
+		OutputLayerProps{NumOutputs: 1, ActFunc: "testRegisteredActFunc"},
+	)
+	if err5a == nil {
+		t.Error("For unregistered OutputLayerProps.ActFunc, did not recieve error")
+	}
+	err5b := actfuncs.Register("testRegisteredActFunc", func(x float64) float64 { return 2 * x }, func(x float64) float64 { return 2 })
+	if err5b != nil {
+		t.Error(err5b)
+	}
+	_, err5c := NewNeuralNetwork(
+		InputLayerProps{NumInputs: 1},
+		[]HiddenLayerProps{
+			HiddenLayerProps{NumNeurons: 2, ActFunc: "testRegisteredActFunc"},
+		},
+		OutputLayerProps{NumOutputs: 1, ActFunc: "testRegisteredActFunc"},
+	)
+	if err5c != nil {
+		t.Error(err5c)
+	}
+
 	nn, err6 := NewNeuralNetwork(
 		InputLayerProps{NumInputs: 1},
 		[]HiddenLayerProps{
@@ -260,8 +257,8 @@ func TestNewNeuralNetwork(t *testing.T) {
 		t.Error(err6)
 	}
 
-	if len(nn.InputLayer.Neurons) != 1 {
-		t.Error("For len(nn.InputLayer.Neurons)", "Expected", 1, "Got", len(nn.InputLayer.Neurons))
+	if nn.InputLayer.NumNeurons != 1 || len(nn.InputLayer.Weights) != 1 {
+		t.Error("For nn.InputLayer.NumNeurons", "Expected", 1, "Got", nn.InputLayer.NumNeurons)
 	}
 	if nn.InputLayer.ActFunc != actfuncs.NoActFunc {
 		t.Error("For nn.InputLayer.ActFunc", "Expected", actfuncs.NoActFunc, "Got", nn.InputLayer.ActFunc)
@@ -273,8 +270,8 @@ func TestNewNeuralNetwork(t *testing.T) {
 	if len(nn.HiddenLayers[0].Inputs) != 1 {
 		t.Error("For len(nn.HiddenLayers[0].Inputs)", "Expected", 1, "Got", len(nn.HiddenLayers[0].Inputs))
 	}
-	if len(nn.HiddenLayers[0].Neurons) != 10 {
-		t.Error("For len(nn.HiddenLayers[0].Neurons)", "Expected", 10, "Got", len(nn.HiddenLayers[0].Neurons))
+	if nn.HiddenLayers[0].NumNeurons != 10 || len(nn.HiddenLayers[0].Weights) != 10*1 {
+		t.Error("For nn.HiddenLayers[0].NumNeurons", "Expected", 10, "Got", nn.HiddenLayers[0].NumNeurons)
 	}
 	if nn.HiddenLayers[0].ActFunc != actfuncs.Sigmoid {
 		t.Error("For nn.HiddenLayers[0].ActFunc", "Expected", actfuncs.Sigmoid, "Got", nn.HiddenLayers[0].ActFunc)
@@ -282,8 +279,8 @@ func TestNewNeuralNetwork(t *testing.T) {
 	if len(nn.HiddenLayers[1].Inputs) != 10 {
 		t.Error("For len(nn.HiddenLayers[1].Inputs)", "Expected", 10, "Got", len(nn.HiddenLayers[1].Inputs))
 	}
-	if len(nn.HiddenLayers[1].Neurons) != 20 {
-		t.Error("For len(nn.HiddenLayers[1].Neurons)", "Expected", 20, "Got", len(nn.HiddenLayers[1].Neurons))
+	if nn.HiddenLayers[1].NumNeurons != 20 || len(nn.HiddenLayers[1].Weights) != 20*10 {
+		t.Error("For nn.HiddenLayers[1].NumNeurons", "Expected", 20, "Got", nn.HiddenLayers[1].NumNeurons)
 	}
 	if nn.HiddenLayers[1].ActFunc != actfuncs.Step {
 		t.Error("For nn.HiddenLayers[1].ActFunc", "Expected", actfuncs.Step, "Got", nn.HiddenLayers[1].ActFunc)
@@ -292,8 +289,8 @@ func TestNewNeuralNetwork(t *testing.T) {
 	if len(nn.OutputLayer.Inputs) != 20 {
 		t.Error("For len(nn.OutputLayer.Inputs)", "Expected", 20, "Got", len(nn.OutputLayer.Inputs))
 	}
-	if len(nn.OutputLayer.Neurons) != 2 {
-		t.Error("For len(nn.OutputLayer.Neurons)", "Expected", 2, "Got", len(nn.OutputLayer.Neurons))
+	if nn.OutputLayer.NumNeurons != 2 || len(nn.OutputLayer.Weights) != 2*20 {
+		t.Error("For nn.OutputLayer.NumNeurons", "Expected", 2, "Got", nn.OutputLayer.NumNeurons)
 	}
 	if nn.OutputLayer.ActFunc != actfuncs.Sigmoid {
 		t.Error("For nn.OutputLayer.ActFunc", "Expected", actfuncs.Sigmoid, "Got", nn.OutputLayer.ActFunc)
@@ -350,3 +347,191 @@ func TestNauralNetworkCalc(t *testing.T) {
 
 	// t.Error("bla")
 }
+
+// newSeedTestNeuralNetwork will return a small network created with the given options.
+func newSeedTestNeuralNetwork(t *testing.T, options ...Option) *NeuralNetwork {
+	nn, err := NewNeuralNetwork(
+		InputLayerProps{NumInputs: 3},
+		[]HiddenLayerProps{
+			HiddenLayerProps{NumNeurons: 4, ActFunc: actfuncs.Sigmoid},
+		},
+		OutputLayerProps{NumOutputs: 2, ActFunc: actfuncs.Sigmoid},
+		options...,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return nn
+}
+
+// sameWeights will return true if both networks have exactly the same weights and biases.
+func sameWeights(nn1 *NeuralNetwork, nn2 *NeuralNetwork) bool {
+	layer2 := nn2.InputLayer
+	for layer1 := nn1.InputLayer; layer1 != nil; layer1 = layer1.NextLayer {
+		if layer2 == nil || len(layer1.Weights) != len(layer2.Weights) {
+			return false
+		}
+		for i := range layer1.Weights {
+			if layer1.Weights[i] != layer2.Weights[i] {
+				return false
+			}
+		}
+		for i := range layer1.Biases {
+			if layer1.Biases[i] != layer2.Biases[i] {
+				return false
+			}
+		}
+		layer2 = layer2.NextLayer
+	}
+	return layer2 == nil
+}
+
+func TestNewNeuralNetworkSeed(t *testing.T) {
+	if !sameWeights(newSeedTestNeuralNetwork(t, WithSeed(42)), newSeedTestNeuralNetwork(t, WithSeed(42))) {
+		t.Error("For two networks with WithSeed(42)", "Expected the same weights")
+	}
+	if sameWeights(newSeedTestNeuralNetwork(t, WithSeed(42)), newSeedTestNeuralNetwork(t, WithSeed(43))) {
+		t.Error("For networks with WithSeed(42) and WithSeed(43)", "Expected different weights")
+	}
+	if !sameWeights(newSeedTestNeuralNetwork(t, WithRand(rand.New(rand.NewSource(7)))), newSeedTestNeuralNetwork(t, WithSeed(7))) {
+		t.Error("For WithRand(rand.New(rand.NewSource(7))) and WithSeed(7)", "Expected the same weights")
+	}
+
+	//networks created back to back without a seed must not share weights
+	if sameWeights(newSeedTestNeuralNetwork(t), newSeedTestNeuralNetwork(t)) {
+		t.Error("For two networks without a seed", "Expected different weights")
+	}
+	nn := newSeedTestNeuralNetwork(t, WithRand(nil))
+	if nn.rng == nil {
+		t.Error("For WithRand(nil)", "Expected the default source to be used")
+	}
+}
+
+func TestNewNeuralNetworkWeightInit(t *testing.T) {
+	var fanIns, fanOuts []int
+	custom := func(rng *rand.Rand, fanIn int, fanOut int) float64 {
+		fanIns = append(fanIns, fanIn)
+		fanOuts = append(fanOuts, fanOut)
+		return 0.5
+	}
+	nn, err := NewNeuralNetwork(
+		InputLayerProps{NumInputs: 3},
+		[]HiddenLayerProps{
+			HiddenLayerProps{NumNeurons: 40, ActFunc: actfuncs.ReLU, WeightInit: initializers.HeUniform},
+			HiddenLayerProps{NumNeurons: 5, ActFunc: actfuncs.ReLU, WeightInit: custom},
+		},
+		OutputLayerProps{NumOutputs: 2, ActFunc: actfuncs.Sigmoid, WeightInit: initializers.Zeros},
+		WithSeed(1),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//HeUniform scales by the fan-in of the first hidden layer, 3, and must give negative values too
+	limit := math.Sqrt(6.0 / 3)
+	negative := false
+	for _, w := range nn.HiddenLayers[0].Weights {
+		if w < -limit || w >= limit {
+			t.Error("For HeUniform weight", "Expected value in", -limit, limit, "Got", w)
+		}
+		negative = negative || w < 0
+	}
+	if !negative {
+		t.Error("For HeUniform weights", "Expected some negative values")
+	}
+
+	if len(fanIns) != 5*40 {
+		t.Fatal("For custom initializer calls", "Expected", 5*40, "Got", len(fanIns))
+	}
+	if fanIns[0] != 40 || fanOuts[0] != 5 {
+		t.Error("For custom initializer fan-in and fan-out", "Expected", 40, 5, "Got", fanIns[0], fanOuts[0])
+	}
+	for _, w := range nn.HiddenLayers[1].Weights {
+		if w != 0.5 {
+			t.Error("For custom initializer weight", "Expected", 0.5, "Got", w)
+		}
+	}
+	for _, w := range nn.OutputLayer.Weights {
+		if w != 0 {
+			t.Error("For Zeros weight", "Expected", 0, "Got", w)
+		}
+	}
+
+	//without an initializer the weights are in [0, 1) as they always were
+	for _, w := range nn.InputLayer.Weights {
+		if w < 0 || w >= 1 {
+			t.Error("For default weight", "Expected value in [0, 1)", "Got", w)
+		}
+	}
+}
+
+func TestNewNeuralNetworkActAlpha(t *testing.T) {
+	nn, err := NewNeuralNetwork(
+		InputLayerProps{NumInputs: 1},
+		[]HiddenLayerProps{
+			HiddenLayerProps{NumNeurons: 1, ActFunc: actfuncs.LeakyReLU, WeightInit: initializers.Constant(1)},
+			HiddenLayerProps{NumNeurons: 1, ActFunc: actfuncs.LeakyReLU, ActAlpha: 0.2, WeightInit: initializers.Constant(1)},
+		},
+		OutputLayerProps{NumOutputs: 1, ActFunc: actfuncs.ELU, ActAlpha: 2, WeightInit: initializers.Constant(1)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nn.HiddenLayers[0].ActAlpha != actfuncs.DefaultLeakyReLUSlope || nn.HiddenLayers[1].ActAlpha != 0.2 || nn.OutputLayer.ActAlpha != 2 {
+		t.Error("For ActAlpha", "Expected", actfuncs.DefaultLeakyReLUSlope, 0.2, 2, "Got", nn.HiddenLayers[0].ActAlpha, nn.HiddenLayers[1].ActAlpha, nn.OutputLayer.ActAlpha)
+	}
+	//the input layer passes -100 through unchanged
+	nn.InputLayer.Weights[0] = 1
+	outputs, err := nn.Predict([]float64{-100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := 2 * math.Expm1(-100*0.01*0.2)
+	if math.Abs(outputs[0]-expected) > 1e-12 {
+		t.Error("For Predict with ActAlpha", "Expected", expected, "Got", outputs[0])
+	}
+
+	_, err = NewNeuralNetwork(
+		InputLayerProps{NumInputs: 1},
+		[]HiddenLayerProps{HiddenLayerProps{NumNeurons: 1, ActFunc: actfuncs.LeakyReLU, ActAlpha: -1}},
+		OutputLayerProps{NumOutputs: 1, ActFunc: actfuncs.NoActFunc},
+	)
+	if err == nil {
+		t.Error("For a negative ActAlpha, did not recieve error")
+	}
+	_, err = NewNeuralNetwork(
+		InputLayerProps{NumInputs: 1},
+		[]HiddenLayerProps{},
+		OutputLayerProps{NumOutputs: 1, ActFunc: actfuncs.ELU, ActAlpha: math.NaN()},
+	)
+	if err == nil {
+		t.Error("For an ActAlpha of NaN, did not recieve error")
+	}
+}
+
+// newBenchmarkNeuralNetwork will return a network with wide layers for the benchmarks.
+func newBenchmarkNeuralNetwork(b *testing.B) *NeuralNetwork {
+	nn, err := NewNeuralNetwork(
+		InputLayerProps{NumInputs: 128},
+		[]HiddenLayerProps{
+			HiddenLayerProps{NumNeurons: 256, ActFunc: actfuncs.ReLU},
+			HiddenLayerProps{NumNeurons: 256, ActFunc: actfuncs.ReLU},
+		},
+		OutputLayerProps{NumOutputs: 10, ActFunc: actfuncs.Softmax},
+	)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return nn
+}
+
+func BenchmarkNeuralNetworkCalc(b *testing.B) {
+	nn := newBenchmarkNeuralNetwork(b)
+	for i := range nn.InputLayer.Inputs {
+		nn.InputLayer.Inputs[i] = float64(i) / 128
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		nn.Calc()
+	}
+}
@@ -0,0 +1,226 @@
+package neuralnet
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/jyakimischak/neuralnet/actfuncs"
+)
+
+//LayerKindDense a fully connected layer, every neuron has a weight for each input and a bias
+const LayerKindDense = "dense"
+
+//LayerKindBatchNorm normalizes each input over the samples of a batch in Fit and with the running mean and variance
+//of the batches otherwise, then scales and shifts it by Gamma and Beta.  Only Fit updates the running statistics,
+//Calc and Backward treat them as constants and Train can not train a network with a BatchNorm layer
+const LayerKindBatchNorm = "batchNorm"
+
+//LayerKindLayerNorm normalizes the inputs of each sample over the layer, then scales and shifts them by Gamma and Beta
+const LayerKindLayerNorm = "layerNorm"
+
+// normEpsilon is added to the variance before taking its root so a constant input does not divide by 0.
+const normEpsilon = 1e-5
+
+// batchNormMomentum is the fraction of the running mean and variance kept each time Fit trains a batch.
+const batchNormMomentum = 0.9
+
+// isValidLayerKind will return true if the layer kind is valid, the empty kind being dense.
+func isValidLayerKind(kind string) bool {
+	return kind == "" || kind == LayerKindDense || isNormLayerKind(kind)
+}
+
+// isNormLayerKind will return true if the layer kind is a normalization layer.
+func isNormLayerKind(kind string) bool {
+	return kind == LayerKindBatchNorm || kind == LayerKindLayerNorm
+}
+
+// newNormLayer will setup a hidden normalization layer of the given kind with numNeurons inputs and outputs and
+// return an instance of it.  Gamma starts at 1, Beta at 0 and the running variance at 1.
+// PrevLayer and NextLayer are NOT setup, they must be set after receiving the instance.
+func newNormLayer(kind string, numNeurons int, actFunc string) *neuralLayer {
+	nl := &neuralLayer{
+		LayerType:    layerTypeHidden,
+		Kind:         kind,
+		NumNeurons:   numNeurons,
+		NumInputs:    numNeurons,
+		ActFunc:      actFunc,
+		ActAlpha:     actfuncs.DefaultAlpha(actFunc),
+		Inputs:       make([]float64, numNeurons),
+		Outputs:      make([]float64, numNeurons),
+		OutBeforeAct: make([]float64, numNeurons),
+		Deltas:       make([]float64, numNeurons),
+		Gamma:        make([]float64, numNeurons),
+		Beta:         make([]float64, numNeurons),
+		GammaGrads:   make([]float64, numNeurons),
+		BetaGrads:    make([]float64, numNeurons),
+		XHat:         make([]float64, numNeurons),
+	}
+	for i := range nl.Gamma {
+		nl.Gamma[i] = 1
+	}
+	if kind == LayerKindBatchNorm {
+		nl.RunningMean = make([]float64, numNeurons)
+		nl.RunningVar = make([]float64, numNeurons)
+		for i := range nl.RunningVar {
+			nl.RunningVar[i] = 1
+		}
+		nl.InvStd = make([]float64, numNeurons)
+	} else {
+		nl.InvStd = make([]float64, 1)
+	}
+	return nl
+}
+
+// isValidNorm will check the parts of a normalization layer that a dense layer does not have.
+func (nl *neuralLayer) isValidNorm() (bool, string) {
+	if nl.LayerType != layerTypeHidden {
+		return false, fmt.Sprintf("A %s layer must be a hidden layer", nl.Kind)
+	}
+	if nl.NumInputs != nl.NumNeurons {
+		return false, fmt.Sprintf("nl.NumInputs != nl.NumNeurons for a %s layer: %d, %d", nl.Kind, nl.NumInputs, nl.NumNeurons)
+	}
+	if len(nl.Weights) != 0 || len(nl.Biases) != 0 {
+		return false, fmt.Sprintf("A %s layer has no Weights or Biases", nl.Kind)
+	}
+	if len(nl.Gamma) != nl.NumNeurons || len(nl.Beta) != nl.NumNeurons || len(nl.GammaGrads) != nl.NumNeurons || len(nl.BetaGrads) != nl.NumNeurons || len(nl.XHat) != nl.NumNeurons {
+		return false, fmt.Sprintf("Invalid layer. Gamma/Beta/GammaGrads/BetaGrads/XHat not initialized properly. Did you call newNormLayer when getting the instance?")
+	}
+	numInvStd := 1
+	if nl.Kind == LayerKindBatchNorm {
+		if len(nl.RunningMean) != nl.NumNeurons || len(nl.RunningVar) != nl.NumNeurons {
+			return false, fmt.Sprintf("len(nl.RunningMean) and len(nl.RunningVar) must be nl.NumNeurons: %d, %d, %d", len(nl.RunningMean), len(nl.RunningVar), nl.NumNeurons)
+		}
+		numInvStd = nl.NumNeurons
+	}
+	if len(nl.InvStd) != numInvStd {
+		return false, fmt.Sprintf("len(nl.InvStd) must be %d and is: %d", numInvStd, len(nl.InvStd))
+	}
+	return true, ""
+}
+
+// normalize will normalize numRows rows of inputs, scale and shift them by Gamma and Beta and write the results to
+// outBeforeAct.  BatchNorm uses the mean and variance of the rows when batchStats is true, updating the running
+// statistics, and the running statistics otherwise.  The normalized inputs and the inverse standard deviations are
+// written to xhat and invStd for backwardNorm unless they are nil, invStd needs one value per neuron for BatchNorm and
+// one per row for LayerNorm.
+func (nl *neuralLayer) normalize(inputs []float64, numRows int, batchStats bool, xhat []float64, invStd []float64,
+	outBeforeAct []float64) {
+	n := nl.NumNeurons
+	if xhat == nil {
+		xhat = outBeforeAct
+	}
+
+	if nl.Kind == LayerKindBatchNorm {
+		for j := 0; j < n; j++ {
+			mean, variance := nl.RunningMean[j], nl.RunningVar[j]
+			if batchStats {
+				mean, variance = 0, 0
+				for iRow := 0; iRow < numRows; iRow++ {
+					mean += inputs[iRow*n+j]
+				}
+				mean /= float64(numRows)
+				for iRow := 0; iRow < numRows; iRow++ {
+					diff := inputs[iRow*n+j] - mean
+					variance += diff * diff
+				}
+				variance /= float64(numRows)
+				//the running variance is the unbiased estimate
+				unbiased := variance
+				if numRows > 1 {
+					unbiased *= float64(numRows) / float64(numRows-1)
+				}
+				nl.RunningMean[j] = batchNormMomentum*nl.RunningMean[j] + (1-batchNormMomentum)*mean
+				nl.RunningVar[j] = batchNormMomentum*nl.RunningVar[j] + (1-batchNormMomentum)*unbiased
+			}
+			is := 1 / math.Sqrt(variance+normEpsilon)
+			if invStd != nil {
+				invStd[j] = is
+			}
+			for iRow := 0; iRow < numRows; iRow++ {
+				xhat[iRow*n+j] = (inputs[iRow*n+j] - mean) * is
+			}
+		}
+	} else {
+		for iRow := 0; iRow < numRows; iRow++ {
+			row := inputs[iRow*n : (iRow+1)*n]
+			mean, variance := 0.0, 0.0
+			for _, x := range row {
+				mean += x
+			}
+			mean /= float64(n)
+			for _, x := range row {
+				variance += (x - mean) * (x - mean)
+			}
+			variance /= float64(n)
+			is := 1 / math.Sqrt(variance+normEpsilon)
+			if invStd != nil {
+				invStd[iRow] = is
+			}
+			for j, x := range row {
+				xhat[iRow*n+j] = (x - mean) * is
+			}
+		}
+	}
+
+	for iRow := 0; iRow < numRows; iRow++ {
+		for j := 0; j < n; j++ {
+			outBeforeAct[iRow*n+j] = nl.Gamma[j]*xhat[iRow*n+j] + nl.Beta[j]
+		}
+	}
+}
+
+// backwardNorm will accumulate the gradients of Gamma and Beta from numRows rows of deltas and the xhat and invStd
+// written by normalize, and set inputGrads to the gradient of the loss with respect to the inputs of every row unless
+// it is nil.  batchStats must match the call to normalize.
+func (nl *neuralLayer) backwardNorm(deltas []float64, numRows int, xhat []float64, invStd []float64, batchStats bool,
+	inputGrads []float64) {
+	n := nl.NumNeurons
+	for iRow := 0; iRow < numRows; iRow++ {
+		for j := 0; j < n; j++ {
+			nl.GammaGrads[j] += deltas[iRow*n+j] * xhat[iRow*n+j]
+			nl.BetaGrads[j] += deltas[iRow*n+j]
+		}
+	}
+	if inputGrads == nil {
+		return
+	}
+
+	switch {
+	case nl.Kind == LayerKindBatchNorm && !batchStats:
+		//the running statistics are constants so each input is only scaled
+		for iRow := 0; iRow < numRows; iRow++ {
+			for j := 0; j < n; j++ {
+				inputGrads[iRow*n+j] = deltas[iRow*n+j] * nl.Gamma[j] * invStd[j]
+			}
+		}
+	case nl.Kind == LayerKindBatchNorm:
+		//each input also moves the mean and variance of its column
+		for j := 0; j < n; j++ {
+			sum, sumXHat := 0.0, 0.0
+			for iRow := 0; iRow < numRows; iRow++ {
+				dxhat := deltas[iRow*n+j] * nl.Gamma[j]
+				sum += dxhat
+				sumXHat += dxhat * xhat[iRow*n+j]
+			}
+			for iRow := 0; iRow < numRows; iRow++ {
+				dxhat := deltas[iRow*n+j] * nl.Gamma[j]
+				inputGrads[iRow*n+j] = invStd[j] / float64(numRows) *
+					(float64(numRows)*dxhat - sum - xhat[iRow*n+j]*sumXHat)
+			}
+		}
+	default:
+		//each input also moves the mean and variance of its row
+		for iRow := 0; iRow < numRows; iRow++ {
+			sum, sumXHat := 0.0, 0.0
+			for j := 0; j < n; j++ {
+				dxhat := deltas[iRow*n+j] * nl.Gamma[j]
+				sum += dxhat
+				sumXHat += dxhat * xhat[iRow*n+j]
+			}
+			for j := 0; j < n; j++ {
+				dxhat := deltas[iRow*n+j] * nl.Gamma[j]
+				inputGrads[iRow*n+j] = invStd[iRow] / float64(n) * (float64(n)*dxhat - sum - xhat[iRow*n+j]*sumXHat)
+			}
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package neuralnet
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jyakimischak/neuralnet/actfuncs"
+	"github.com/jyakimischak/neuralnet/initializers"
+	"github.com/jyakimischak/neuralnet/losses"
+	"github.com/jyakimischak/neuralnet/optimizers"
+)
+
+// newNormTestNeuralNetwork will return a seeded network with a normalization layer of the given kind between two
+// dense hidden layers.
+func newNormTestNeuralNetwork(t *testing.T, kind string) *NeuralNetwork {
+	nn, err := NewNeuralNetwork(
+		InputLayerProps{NumInputs: 2},
+		[]HiddenLayerProps{
+			HiddenLayerProps{NumNeurons: 4, ActFunc: actfuncs.NoActFunc, WeightInit: initializers.XavierNormal},
+			HiddenLayerProps{Kind: kind, ActFunc: actfuncs.Tanh},
+			HiddenLayerProps{NumNeurons: 3, ActFunc: actfuncs.Tanh, WeightInit: initializers.XavierNormal},
+		},
+		OutputLayerProps{NumOutputs: 1, ActFunc: actfuncs.Sigmoid, WeightInit: initializers.XavierNormal},
+		WithSeed(1),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return nn
+}
+
+func TestNewNormLayer(t *testing.T) {
+	for _, kind := range []string{LayerKindBatchNorm, LayerKindLayerNorm} {
+		nn := newNormTestNeuralNetwork(t, kind)
+		norm := nn.HiddenLayers[1]
+		if norm.Kind != kind || norm.NumNeurons != 4 || norm.NumInputs != 4 {
+			t.Error("For a", kind, "layer", "Expected 4 neurons and inputs", "Got", norm.NumNeurons, norm.NumInputs)
+		}
+		if norm.Gamma[0] != 1 || norm.Beta[0] != 0 {
+			t.Error("For the initial Gamma and Beta", "Expected", 1, 0, "Got", norm.Gamma[0], norm.Beta[0])
+		}
+		if nn.HiddenLayers[2].NumInputs != 4 {
+			t.Error("For the layer after", kind, "NumInputs", "Expected", 4, "Got", nn.HiddenLayers[2].NumInputs)
+		}
+		_, err := nn.Bias(2, 0)
+		if err == nil {
+			t.Error("For the bias of a", kind, "layer, did not recieve error")
+		}
+		params := nn.Params()
+		if params[4].Name != "layer2.gamma" || params[5].Name != "layer2.beta" || params[4].Decay {
+			t.Error("For the params of a", kind, "layer", "Expected layer2.gamma and layer2.beta without decay", "Got", params[4].Name, params[5].Name)
+		}
+	}
+	if nn := newNormTestNeuralNetwork(t, LayerKindLayerNorm); nn.HiddenLayers[1].RunningMean != nil {
+		t.Error("For LayerNorm RunningMean", "Expected", nil, "Got", nn.HiddenLayers[1].RunningMean)
+	}
+
+	_, err := NewNeuralNetwork(
+		InputLayerProps{NumInputs: 2},
+		[]HiddenLayerProps{HiddenLayerProps{Kind: LayerKindBatchNorm, NumNeurons: 3}},
+		OutputLayerProps{NumOutputs: 1, ActFunc: actfuncs.Sigmoid},
+	)
+	if err == nil {
+		t.Error("For a BatchNorm layer with a different number of neurons than inputs, did not recieve error")
+	}
+	_, err = NewNeuralNetwork(
+		InputLayerProps{NumInputs: 2},
+		[]HiddenLayerProps{HiddenLayerProps{Kind: "invalid", NumNeurons: 3}},
+		OutputLayerProps{NumOutputs: 1, ActFunc: actfuncs.Sigmoid},
+	)
+	if err == nil {
+		t.Error("For an unknown layer kind, did not recieve error")
+	}
+}
+
+func TestNormCalc(t *testing.T) {
+	inputs := []float64{0.4, -0.2}
+
+	//LayerNorm gives every sample a mean of 0 and a variance of 1 before the scale and shift
+	nn := newNormTestNeuralNetwork(t, LayerKindLayerNorm)
+	copy(nn.InputLayer.Inputs, inputs)
+	nn.Calc()
+	ln := nn.HiddenLayers[1]
+	mean, variance := meanVariance(ln.OutBeforeAct)
+	_, inputVariance := meanVariance(ln.Inputs)
+	//normEpsilon keeps the variance just under 1
+	expectedVariance := inputVariance / (inputVariance + normEpsilon)
+	if math.Abs(mean) > 1e-12 || math.Abs(variance-expectedVariance) > 1e-12 {
+		t.Error("For the mean and variance of LayerNorm", "Expected", 0, expectedVariance, "Got", mean, variance)
+	}
+
+	//BatchNorm starts with a running mean of 0 and variance of 1, so outside of training it barely changes its inputs
+	nn = newNormTestNeuralNetwork(t, LayerKindBatchNorm)
+	copy(nn.InputLayer.Inputs, inputs)
+	nn.Calc()
+	bn := nn.HiddenLayers[1]
+	for i := range bn.Inputs {
+		expected := bn.Inputs[i] / math.Sqrt(1+normEpsilon)
+		if math.Abs(bn.OutBeforeAct[i]-expected) > 1e-12 {
+			t.Error("For BatchNorm with the initial running statistics", "Expected", expected, "Got", bn.OutBeforeAct[i])
+		}
+	}
+
+	//Predict matches Calc for both kinds
+	for _, kind := range []string{LayerKindBatchNorm, LayerKindLayerNorm} {
+		nn = newNormTestNeuralNetwork(t, kind)
+		nn.HiddenLayers[1].Gamma[2] = 1.5
+		nn.HiddenLayers[1].Beta[1] = -0.5
+		copy(nn.InputLayer.Inputs, inputs)
+		nn.Calc()
+		outputs, _ := nn.Predict(inputs)
+		batchOutputs, _ := nn.PredictBatch([][]float64{inputs, inputs})
+		if outputs[0] != nn.OutputLayer.Outputs[0] || batchOutputs[1][0] != outputs[0] {
+			t.Error("For Predict and PredictBatch with", kind, "Expected", nn.OutputLayer.Outputs[0], "Got", outputs[0], batchOutputs[1][0])
+		}
+	}
+}
+
+func TestNormBackward(t *testing.T) {
+	//the gradients of a single sample from Calc and Backward
+	for _, kind := range []string{LayerKindBatchNorm, LayerKindLayerNorm} {
+		nn := newNormTestNeuralNetwork(t, kind)
+		nn.HiddenLayers[1].Gamma[2] = 1.5
+		nn.HiddenLayers[1].Beta[1] = -0.5
+		report, err := GradCheck(nn, []float64{0.4, -0.2}, []float64{1}, losses.BinaryCrossEntropy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.MaxRelativeError > 1e-5 {
+			t.Error("For GradCheck with", kind, "Expected a relative error under", 1e-5, "Got", report.MaxRelativeError, report.Layers)
+		}
+	}
+}
+
+func TestNormBackwardBatch(t *testing.T) {
+	//the gradients of a batch, where BatchNorm normalizes with the statistics of the batch.  No input is all 0, which
+	//would give LayerNorm a constant row with gradients too steep for a finite difference.
+	dataset := Dataset{
+		Inputs:  [][]float64{{0.4, -0.2}, {-0.7, 0.9}, {0.3, 0.8}, {-0.5, -0.6}},
+		Targets: [][]float64{{1}, {0}, {1}, {0}},
+	}
+	for _, kind := range []string{LayerKindBatchNorm, LayerKindLayerNorm} {
+		nn := newNormTestNeuralNetwork(t, kind)
+		nn.HiddenLayers[1].Gamma[2] = 1.5
+		nn.HiddenLayers[1].Beta[1] = -0.5
+		batch := nn.newTrainingBatch(4)
+		batch.load(dataset, []int{0, 1, 2, 3})
+		lossFn := func() float64 {
+			return batch.forwardBackward(losses.BinaryCrossEntropy)
+		}
+
+		nn.ZeroGrads()
+		lossFn()
+		//every call to lossFn adds to the gradients, so check against a copy taken before any of them
+		params := nn.Params()
+		grads := make([][]float64, len(params))
+		for i, p := range params {
+			grads[i] = append([]float64(nil), p.Grads...)
+		}
+		for i, p := range params {
+			checkParamGradients(t, kind+" batch "+p.Name, p.Values, grads[i], lossFn)
+		}
+	}
+}
+
+// meanVariance will return the mean and the biased variance of the values.
+func meanVariance(values []float64) (float64, float64) {
+	mean, variance := 0.0, 0.0
+	for _, x := range values {
+		mean += x / float64(len(values))
+	}
+	for _, x := range values {
+		variance += (x - mean) * (x - mean) / float64(len(values))
+	}
+	return mean, variance
+}
+
+func TestFitNorm(t *testing.T) {
+	for _, kind := range []string{LayerKindBatchNorm, LayerKindLayerNorm} {
+		nn := newNormTestNeuralNetwork(t, kind)
+		snapshot := nn.Snapshot()
+		history, err := nn.Fit(newXORDataset(), FitConfig{
+			Epochs:    300,
+			Loss:      losses.BinaryCrossEntropy,
+			Optimizer: optimizers.NewAdam(0.05),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		loss, _ := nn.Evaluate(newXORDataset(), losses.BinaryCrossEntropy)
+		if loss >= history[0].Loss || loss > 0.2 {
+			t.Error("For XOR loss with", kind, "Expected it to drop below", 0.2, "from", history[0].Loss, "Got", loss)
+		}
+
+		//the scale, shift and running statistics are part of a snapshot
+		norm := nn.HiddenLayers[1]
+		if kind == LayerKindBatchNorm && norm.RunningMean[0] == 0 {
+			t.Error("For RunningMean after training", "Expected it to change from", 0)
+		}
+
+		//Train only supports layers that do not need the statistics of a batch
+		_, err = nn.Train([]float64{0, 1}, []float64{1}, 0.1)
+		if kind == LayerKindBatchNorm && err == nil {
+			t.Error("For Train with", kind, "did not recieve error")
+		}
+		if kind == LayerKindLayerNorm && err != nil {
+			t.Error("For Train with", kind, "Expected no error", "Got", err)
+		}
+		err = nn.Restore(snapshot)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if norm.Gamma[0] != 1 || (kind == LayerKindBatchNorm && (norm.RunningMean[0] != 0 || norm.RunningVar[0] != 1)) {
+			t.Error("For Gamma and the running statistics after Restore", "Expected", 1, 0, 1, "Got", norm.Gamma[0], norm.RunningMean, norm.RunningVar)
+		}
+	}
+}
+
+func TestFitBatchNormSingleRow(t *testing.T) {
+	//a single sample has no variance so no batch may have only one
+	nn := newNormTestNeuralNetwork(t, LayerKindBatchNorm)
+	for _, batchSize := range []int{1, 3} {
+		_, err := nn.Fit(newXORDataset(), FitConfig{
+			Epochs:    1,
+			BatchSize: batchSize,
+			Loss:      losses.BinaryCrossEntropy,
+			Optimizer: optimizers.NewAdam(0.05),
+		})
+		if err == nil {
+			t.Error("For BatchNorm with BatchSize", batchSize, "of 4 samples, did not recieve error")
+		}
+	}
+	_, err := nn.Fit(newXORDataset(), FitConfig{
+		Epochs:    1,
+		BatchSize: 2,
+		Loss:      losses.BinaryCrossEntropy,
+		Optimizer: optimizers.NewAdam(0.05),
+	})
+	if err != nil {
+		t.Error("For BatchNorm with BatchSize 2 of 4 samples", "Expected", nil, "Got", err)
+	}
+
+	//a layer norm has no batch statistics
+	nn = newNormTestNeuralNetwork(t, LayerKindLayerNorm)
+	_, err = nn.Fit(newXORDataset(), FitConfig{
+		Epochs:    1,
+		BatchSize: 1,
+		Loss:      losses.BinaryCrossEntropy,
+		Optimizer: optimizers.NewAdam(0.05),
+	})
+	if err != nil {
+		t.Error("For LayerNorm with BatchSize 1", "Expected", nil, "Got", err)
+	}
+}
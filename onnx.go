@@ -0,0 +1,314 @@
+package neuralnet
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/jyakimischak/neuralnet/actfuncs"
+)
+
+// onnxIRVersion is the version of the ONNX file format ExportONNX writes.
+const onnxIRVersion = 7
+
+// onnxOpsetVersion is the version of the ONNX operators ExportONNX uses.
+const onnxOpsetVersion = 13
+
+// onnxFloat is the ONNX data type of every tensor ExportONNX writes, float32.
+const onnxFloat = 1
+
+// onnxAttributeFloat and onnxAttributeInt are the ONNX types of node attributes.
+const onnxAttributeFloat = 1
+const onnxAttributeInt = 2
+
+//*************************************************************************************************************
+//onnxGraph
+
+// onnxGraph is the graph of an ONNX model, built up a node at a time before it is encoded.
+type onnxGraph struct {
+	nodes        []onnxNode
+	initializers []onnxTensor
+	numInputs    int
+	numOutputs   int
+}
+
+// onnxNode is one operator of the graph.  It is named after its output.
+type onnxNode struct {
+	opType     string
+	inputs     []string
+	output     string
+	attributes []onnxAttribute
+}
+
+// onnxAttribute is an attribute of a node, a float or an int depending on attributeType.
+type onnxAttribute struct {
+	name          string
+	attributeType int
+	f             float32
+	i             int64
+}
+
+// onnxTensor is a constant of the graph, such as the weights of a layer.  A tensor without dims is a scalar.
+type onnxTensor struct {
+	name   string
+	dims   []int64
+	values []float32
+}
+
+// addNode will add a node to the graph.
+func (g *onnxGraph) addNode(opType string, output string, inputs []string, attributes ...onnxAttribute) {
+	g.nodes = append(g.nodes, onnxNode{opType: opType, inputs: inputs, output: output, attributes: attributes})
+}
+
+// addInitializer will add a constant to the graph, the values are rounded to float32.
+func (g *onnxGraph) addInitializer(name string, dims []int64, values []float64) {
+	values32 := make([]float32, len(values))
+	for i, v := range values {
+		values32[i] = float32(v)
+	}
+	g.initializers = append(g.initializers, onnxTensor{name: name, dims: dims, values: values32})
+}
+
+// addScalar will add a scalar constant to the graph if it does not have one with the name already, and return the
+// name.
+func (g *onnxGraph) addScalar(name string, value float64) string {
+	for _, t := range g.initializers {
+		if t.name == name {
+			return name
+		}
+	}
+	g.addInitializer(name, nil, []float64{value})
+	return name
+}
+
+// addActFunc will add the nodes that apply the activation function to x and write the result to output, the names of
+// any nodes in between start with prefix.  alpha is the alpha of LeakyReLU and ELU, 0 for the default.  NoActFunc is
+// not handled here since it needs no node.
+func (g *onnxGraph) addActFunc(actFunc string, alpha float64, prefix string, x string, output string) error {
+	if alpha == 0 {
+		alpha = actfuncs.DefaultAlpha(actFunc)
+	}
+	switch actFunc {
+	case actfuncs.Step:
+		//sign is 1 for x > 0 and 0 or -1 otherwise
+		g.addNode("Sign", prefix+".sign", []string{x})
+		g.addNode("Relu", output, []string{prefix + ".sign"})
+	case actfuncs.Sigmoid:
+		g.addNode("Sigmoid", output, []string{x})
+	case actfuncs.ReLU:
+		g.addNode("Relu", output, []string{x})
+	case actfuncs.LeakyReLU:
+		g.addNode("LeakyRelu", output, []string{x}, onnxFloatAttribute("alpha", alpha))
+	case actfuncs.ELU:
+		g.addNode("Elu", output, []string{x}, onnxFloatAttribute("alpha", alpha))
+	case actfuncs.Tanh:
+		g.addNode("Tanh", output, []string{x})
+	case actfuncs.Softplus:
+		g.addNode("Softplus", output, []string{x})
+	case actfuncs.GELU:
+		//x * 0.5 * (1 + erf(x / sqrt(2))), Gelu is not an operator until opset 20
+		g.addNode("Div", prefix+".div", []string{x, g.addScalar("sqrt2", math.Sqrt2)})
+		g.addNode("Erf", prefix+".erf", []string{prefix + ".div"})
+		g.addNode("Add", prefix+".add", []string{prefix + ".erf", g.addScalar("one", 1)})
+		g.addNode("Mul", prefix+".mul", []string{x, prefix + ".add"})
+		g.addNode("Mul", output, []string{prefix + ".mul", g.addScalar("half", 0.5)})
+	case actfuncs.Swish:
+		g.addNode("Sigmoid", prefix+".sigmoid", []string{x})
+		g.addNode("Mul", output, []string{x, prefix + ".sigmoid"})
+	case actfuncs.Softmax:
+		g.addNode("Softmax", output, []string{x}, onnxIntAttribute("axis", 1))
+	default:
+		return fmt.Errorf("Activation function can not be exported to ONNX: %s", actFunc)
+	}
+	return nil
+}
+
+// onnxFloatAttribute will return a float attribute.
+func onnxFloatAttribute(name string, f float64) onnxAttribute {
+	return onnxAttribute{name: name, attributeType: onnxAttributeFloat, f: float32(f)}
+}
+
+// onnxIntAttribute will return an int attribute.
+func onnxIntAttribute(name string, i int64) onnxAttribute {
+	return onnxAttribute{name: name, attributeType: onnxAttributeInt, i: i}
+}
+
+//*************************************************************************************************************
+//NeuralNetwork
+
+// ExportONNX will write the network to w as an ONNX model, so it can be run by other runtimes.  The model has one
+// input, "input", with a row of NumInputs values for each sample, and one output, "output", with a row of outputs for
+// each sample, any number of samples can be run at once.  Each layer is a Gemm node with the layer's weights and
+// biases followed by the nodes of its activation function, computed in float32.  The ActAlpha of a LeakyReLU or ELU
+// layer is exported as the alpha of its node, dropout is left out as it is for Predict.
+//
+// Only dense layers and the built in activation functions can be exported, an error is returned for a normalization
+// layer or an activation function added with actfuncs.Register.
+func (nn *NeuralNetwork) ExportONNX(w io.Writer) error {
+	if nn.InputLayer == nil || nn.OutputLayer == nil {
+		return errors.New("Invalid neural network. Did you call NewNeuralNetwork when getting the instance?")
+	}
+	isValid, invalidMsg := nn.IsValid()
+	if !isValid {
+		return errors.New(invalidMsg)
+	}
+
+	g := &onnxGraph{numInputs: nn.InputLayer.NumInputs, numOutputs: nn.OutputLayer.NumNeurons}
+	x := "input"
+	iLayer := 0
+	for layer := nn.InputLayer; layer != nil; layer = layer.NextLayer {
+		if layer.Kind != LayerKindDense {
+			return fmt.Errorf("layer %d: %s layers can not be exported to ONNX, only %s layers", iLayer, layer.Kind, LayerKindDense)
+		}
+		prefix := fmt.Sprintf("layer%d", iLayer)
+		output := prefix + ".outputs"
+		if layer.LayerType == layerTypeOutput {
+			output = "output"
+		}
+		outBeforeAct := output
+		if layer.ActFunc != actfuncs.NoActFunc {
+			outBeforeAct = prefix + ".outBeforeAct"
+		}
+
+		//Gemm calculates x * transpose(weights) + biases
+		g.addInitializer(prefix+".weights", []int64{int64(layer.NumNeurons), int64(layer.NumInputs)}, layer.Weights)
+		g.addInitializer(prefix+".biases", []int64{int64(layer.NumNeurons)}, layer.Biases)
+		g.addNode("Gemm", outBeforeAct, []string{x, prefix + ".weights", prefix + ".biases"}, onnxIntAttribute("transB", 1))
+		if layer.ActFunc != actfuncs.NoActFunc {
+			err := g.addActFunc(layer.ActFunc, layer.ActAlpha, prefix, outBeforeAct, output)
+			if err != nil {
+				return fmt.Errorf("layer %d: %v", iLayer, err)
+			}
+		}
+		x = output
+		iLayer++
+	}
+
+	_, err := w.Write(g.encode())
+	return err
+}
+
+//*************************************************************************************************************
+//protobuf
+
+// encode will return the graph as a serialized ONNX ModelProto.  The field numbers are those of onnx.proto.
+func (g *onnxGraph) encode() []byte {
+	var model protoEncoder
+	model.varintField(1, onnxIRVersion)
+	model.stringField(2, "neuralnet")
+	model.messageField(7, func(graph *protoEncoder) {
+		for _, n := range g.nodes {
+			graph.messageField(1, n.encode)
+		}
+		graph.stringField(2, "neuralnet")
+		for _, t := range g.initializers {
+			graph.messageField(5, t.encode)
+		}
+		graph.messageField(11, onnxValueInfo("input", g.numInputs))
+		graph.messageField(12, onnxValueInfo("output", g.numOutputs))
+	})
+	model.messageField(8, func(opset *protoEncoder) {
+		opset.varintField(2, onnxOpsetVersion)
+	})
+	return model.buf
+}
+
+// encode will write the node as a NodeProto.
+func (n onnxNode) encode(e *protoEncoder) {
+	for _, input := range n.inputs {
+		e.stringField(1, input)
+	}
+	e.stringField(2, n.output)
+	e.stringField(3, n.output)
+	e.stringField(4, n.opType)
+	for _, a := range n.attributes {
+		e.messageField(5, func(attribute *protoEncoder) {
+			attribute.stringField(1, a.name)
+			if a.attributeType == onnxAttributeFloat {
+				attribute.floatField(2, a.f)
+			} else {
+				attribute.varintField(3, uint64(a.i))
+			}
+			attribute.varintField(20, uint64(a.attributeType))
+		})
+	}
+}
+
+// encode will write the tensor as a TensorProto with its values as little-endian raw data.
+func (t onnxTensor) encode(e *protoEncoder) {
+	for _, dim := range t.dims {
+		e.varintField(1, uint64(dim))
+	}
+	e.varintField(2, onnxFloat)
+	e.stringField(8, t.name)
+	raw := make([]byte, 4*len(t.values))
+	for i, v := range t.values {
+		binary.LittleEndian.PutUint32(raw[4*i:], math.Float32bits(v))
+	}
+	e.bytesField(9, raw)
+}
+
+// onnxValueInfo will return a function that writes a ValueInfoProto for a float tensor with any number of rows of
+// numColumns values.
+func onnxValueInfo(name string, numColumns int) func(e *protoEncoder) {
+	return func(e *protoEncoder) {
+		e.stringField(1, name)
+		e.messageField(2, func(typeProto *protoEncoder) {
+			typeProto.messageField(1, func(tensorType *protoEncoder) {
+				tensorType.varintField(1, onnxFloat)
+				tensorType.messageField(2, func(shape *protoEncoder) {
+					shape.messageField(1, func(dim *protoEncoder) { dim.stringField(2, "N") })
+					shape.messageField(1, func(dim *protoEncoder) { dim.varintField(1, uint64(numColumns)) })
+				})
+			})
+		})
+	}
+}
+
+// protoEncoder writes the fields of a protocol buffer message.
+type protoEncoder struct {
+	buf []byte
+}
+
+// protobuf wire types
+const protoVarint = 0
+const protoBytes = 2
+const protoFixed32 = 5
+
+// tag will write the field number and wire type of a field.
+func (e *protoEncoder) tag(field int, wireType int) {
+	e.buf = binary.AppendUvarint(e.buf, uint64(field)<<3|uint64(wireType))
+}
+
+// varintField will write an integer field.
+func (e *protoEncoder) varintField(field int, v uint64) {
+	e.tag(field, protoVarint)
+	e.buf = binary.AppendUvarint(e.buf, v)
+}
+
+// floatField will write a float field.
+func (e *protoEncoder) floatField(field int, f float32) {
+	e.tag(field, protoFixed32)
+	e.buf = binary.LittleEndian.AppendUint32(e.buf, math.Float32bits(f))
+}
+
+// bytesField will write a bytes field.
+func (e *protoEncoder) bytesField(field int, b []byte) {
+	e.tag(field, protoBytes)
+	e.buf = binary.AppendUvarint(e.buf, uint64(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+// stringField will write a string field.
+func (e *protoEncoder) stringField(field int, s string) {
+	e.bytesField(field, []byte(s))
+}
+
+// messageField will write an embedded message field whose fields are written by encode.
+func (e *protoEncoder) messageField(field int, encode func(e *protoEncoder)) {
+	var m protoEncoder
+	encode(&m)
+	e.bytesField(field, m.buf)
+}
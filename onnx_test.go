@@ -0,0 +1,424 @@
+package neuralnet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/jyakimischak/neuralnet/actfuncs"
+	"github.com/jyakimischak/neuralnet/initializers"
+)
+
+//*************************************************************************************************************
+//protobuf decoding
+
+// protoField is a field of a decoded protocol buffer message, value holds a varint or fixed32 and data the bytes of a
+// length delimited field.
+type protoField struct {
+	num   int
+	value uint64
+	data  []byte
+}
+
+// decodeProto will split a protocol buffer message into its fields.
+func decodeProto(t *testing.T, b []byte) []protoField {
+	var fields []protoField
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		if n <= 0 {
+			t.Fatal("For a protobuf tag", "Expected a varint", "Got", b)
+		}
+		b = b[n:]
+		f := protoField{num: int(tag >> 3)}
+		switch tag & 7 {
+		case protoVarint:
+			f.value, n = binary.Uvarint(b)
+			if n <= 0 {
+				t.Fatal("For a protobuf varint", "Expected a varint", "Got", b)
+			}
+			b = b[n:]
+		case protoBytes:
+			length, n := binary.Uvarint(b)
+			if n <= 0 || length > uint64(len(b)-n) {
+				t.Fatal("For a protobuf length", "Expected at most", len(b)-n, "Got", length)
+			}
+			f.data = b[n : n+int(length)]
+			b = b[n+int(length):]
+		case protoFixed32:
+			if len(b) < 4 {
+				t.Fatal("For a protobuf fixed32", "Expected 4 bytes", "Got", len(b))
+			}
+			f.value = uint64(binary.LittleEndian.Uint32(b))
+			b = b[4:]
+		default:
+			t.Fatal("For a protobuf wire type", "Expected", protoVarint, protoBytes, protoFixed32, "Got", tag&7)
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// protoFields will return the fields with the given number.
+func protoFields(fields []protoField, num int) []protoField {
+	var matching []protoField
+	for _, f := range fields {
+		if f.num == num {
+			matching = append(matching, f)
+		}
+	}
+	return matching
+}
+
+// protoStrings will return the values of the string fields with the given number.
+func protoStrings(fields []protoField, num int) []string {
+	var strings []string
+	for _, f := range protoFields(fields, num) {
+		strings = append(strings, string(f.data))
+	}
+	return strings
+}
+
+//*************************************************************************************************************
+//ONNX evaluation
+
+// testTensor is a float32 tensor with up to 2 dims, a scalar has none.
+type testTensor struct {
+	dims   []int64
+	values []float32
+}
+
+// testONNXNode is a decoded NodeProto.
+type testONNXNode struct {
+	opType string
+	inputs []string
+	output string
+	ints   map[string]int64
+	floats map[string]float32
+}
+
+// testONNXModel is a decoded ModelProto.
+type testONNXModel struct {
+	irVersion    uint64
+	opsetVersion uint64
+	nodes        []testONNXNode
+	initializers map[string]testTensor
+	//inputs and outputs are the graph's inputs and outputs, each a name and the dims, -1 for a dim_param
+	inputs  map[string][]int64
+	outputs map[string][]int64
+}
+
+// decodeONNX will decode the model written by ExportONNX.
+func decodeONNX(t *testing.T, b []byte) testONNXModel {
+	model := testONNXModel{initializers: map[string]testTensor{}, inputs: map[string][]int64{}, outputs: map[string][]int64{}}
+	fields := decodeProto(t, b)
+	model.irVersion = protoFields(fields, 1)[0].value
+	model.opsetVersion = protoFields(decodeProto(t, protoFields(fields, 8)[0].data), 2)[0].value
+	graph := decodeProto(t, protoFields(fields, 7)[0].data)
+
+	for _, nodeField := range protoFields(graph, 1) {
+		nodeFields := decodeProto(t, nodeField.data)
+		node := testONNXNode{
+			opType: protoStrings(nodeFields, 4)[0],
+			inputs: protoStrings(nodeFields, 1),
+			output: protoStrings(nodeFields, 2)[0],
+			ints:   map[string]int64{},
+			floats: map[string]float32{},
+		}
+		for _, attributeField := range protoFields(nodeFields, 5) {
+			attribute := decodeProto(t, attributeField.data)
+			name := protoStrings(attribute, 1)[0]
+			switch protoFields(attribute, 20)[0].value {
+			case onnxAttributeFloat:
+				node.floats[name] = math.Float32frombits(uint32(protoFields(attribute, 2)[0].value))
+			case onnxAttributeInt:
+				node.ints[name] = int64(protoFields(attribute, 3)[0].value)
+			}
+		}
+		model.nodes = append(model.nodes, node)
+	}
+
+	for _, tensorField := range protoFields(graph, 5) {
+		tensor := decodeProto(t, tensorField.data)
+		if protoFields(tensor, 2)[0].value != onnxFloat {
+			t.Fatal("For the initializer data type", "Expected", onnxFloat, "Got", protoFields(tensor, 2)[0].value)
+		}
+		var tt testTensor
+		for _, dim := range protoFields(tensor, 1) {
+			tt.dims = append(tt.dims, int64(dim.value))
+		}
+		raw := protoFields(tensor, 9)[0].data
+		for i := 0; i < len(raw); i += 4 {
+			tt.values = append(tt.values, math.Float32frombits(binary.LittleEndian.Uint32(raw[i:])))
+		}
+		model.initializers[protoStrings(tensor, 8)[0]] = tt
+	}
+
+	for _, c := range []struct {
+		num   int
+		infos map[string][]int64
+	}{{11, model.inputs}, {12, model.outputs}} {
+		for _, infoField := range protoFields(graph, c.num) {
+			info := decodeProto(t, infoField.data)
+			tensorType := decodeProto(t, protoFields(decodeProto(t, protoFields(info, 2)[0].data), 1)[0].data)
+			shape := decodeProto(t, protoFields(tensorType, 2)[0].data)
+			var dims []int64
+			for _, dimField := range protoFields(shape, 1) {
+				dim := decodeProto(t, dimField.data)
+				if len(protoFields(dim, 2)) > 0 {
+					dims = append(dims, -1)
+				} else {
+					dims = append(dims, int64(protoFields(dim, 1)[0].value))
+				}
+			}
+			c.infos[protoStrings(info, 1)[0]] = dims
+		}
+	}
+	return model
+}
+
+// run will evaluate the model on a batch of inputs the way an ONNX runtime would, in float32, and return the output.
+func (model testONNXModel) run(t *testing.T, inputs [][]float64) testTensor {
+	values := map[string]testTensor{}
+	for name, tensor := range model.initializers {
+		values[name] = tensor
+	}
+	input := testTensor{dims: []int64{int64(len(inputs)), int64(len(inputs[0]))}}
+	for _, row := range inputs {
+		for _, x := range row {
+			input.values = append(input.values, float32(x))
+		}
+	}
+	values["input"] = input
+
+	for _, node := range model.nodes {
+		in := make([]testTensor, len(node.inputs))
+		for i, name := range node.inputs {
+			tensor, ok := values[name]
+			if !ok {
+				t.Fatal("For the input of", node.opType, "node", node.output, "Expected", name, "to be calculated already")
+			}
+			in[i] = tensor
+		}
+		var out testTensor
+		switch node.opType {
+		case "Gemm":
+			out = gemm(t, in[0], in[1], in[2], node.ints["transB"] == 1)
+		case "Add", "Mul", "Div":
+			out = broadcast(t, node.opType, in[0], in[1])
+		case "Softmax":
+			if node.ints["axis"] != 1 {
+				t.Fatal("For the Softmax axis", "Expected", 1, "Got", node.ints["axis"])
+			}
+			out = softmaxRows(in[0])
+		default:
+			out = elementwise(t, node, in[0])
+		}
+		values[node.output] = out
+	}
+	return values["output"]
+}
+
+// gemm will calculate a * b + c, or a * transpose(b) + c, where c is a row added to every row.
+func gemm(t *testing.T, a testTensor, b testTensor, c testTensor, transB bool) testTensor {
+	m, k := a.dims[0], a.dims[1]
+	n := b.dims[1]
+	if transB {
+		n = b.dims[0]
+	}
+	if (transB && b.dims[1] != k) || (!transB && b.dims[0] != k) || int64(len(c.values)) != n {
+		t.Fatal("For the dims of Gemm", "Expected them to match", "Got", a.dims, b.dims, c.dims)
+	}
+	out := testTensor{dims: []int64{m, n}, values: make([]float32, m*n)}
+	for i := int64(0); i < m; i++ {
+		for j := int64(0); j < n; j++ {
+			sum := c.values[j]
+			for l := int64(0); l < k; l++ {
+				if transB {
+					sum += a.values[i*k+l] * b.values[j*k+l]
+				} else {
+					sum += a.values[i*k+l] * b.values[l*n+j]
+				}
+			}
+			out.values[i*n+j] = sum
+		}
+	}
+	return out
+}
+
+// broadcast will apply a binary operator to tensors of the same shape or a tensor and a scalar.
+func broadcast(t *testing.T, opType string, a testTensor, b testTensor) testTensor {
+	out := a
+	if len(a.values) == 1 {
+		out = b
+	}
+	out = testTensor{dims: out.dims, values: make([]float32, len(out.values))}
+	if len(a.values) != len(out.values) && len(a.values) != 1 || len(b.values) != len(out.values) && len(b.values) != 1 {
+		t.Fatal("For the shapes of", opType, "Expected the same or a scalar", "Got", a.dims, b.dims)
+	}
+	for i := range out.values {
+		x, y := a.values[i%len(a.values)], b.values[i%len(b.values)]
+		switch opType {
+		case "Add":
+			out.values[i] = x + y
+		case "Mul":
+			out.values[i] = x * y
+		case "Div":
+			out.values[i] = x / y
+		}
+	}
+	return out
+}
+
+// softmaxRows will apply softmax across each row of a 2 dim tensor.
+func softmaxRows(x testTensor) testTensor {
+	out := testTensor{dims: x.dims, values: make([]float32, len(x.values))}
+	cols := int(x.dims[1])
+	for i := 0; i < len(x.values); i += cols {
+		max := x.values[i]
+		for _, v := range x.values[i : i+cols] {
+			max = float32(math.Max(float64(max), float64(v)))
+		}
+		sum := float32(0)
+		for j, v := range x.values[i : i+cols] {
+			out.values[i+j] = float32(math.Exp(float64(v - max)))
+			sum += out.values[i+j]
+		}
+		for j := range out.values[i : i+cols] {
+			out.values[i+j] /= sum
+		}
+	}
+	return out
+}
+
+// elementwise will apply a unary operator to every value of a tensor.
+func elementwise(t *testing.T, node testONNXNode, x testTensor) testTensor {
+	out := testTensor{dims: x.dims, values: make([]float32, len(x.values))}
+	for i, v32 := range x.values {
+		v := float64(v32)
+		var y float64
+		switch node.opType {
+		case "Sign":
+			y = math.Copysign(1, v)
+			if v == 0 {
+				y = 0
+			}
+		case "Relu":
+			y = math.Max(v, 0)
+		case "LeakyRelu":
+			y = v
+			if v < 0 {
+				y = float64(node.floats["alpha"]) * v
+			}
+		case "Elu":
+			y = v
+			if v < 0 {
+				y = float64(node.floats["alpha"]) * math.Expm1(v)
+			}
+		case "Sigmoid":
+			y = 1 / (1 + math.Exp(-v))
+		case "Tanh":
+			y = math.Tanh(v)
+		case "Softplus":
+			y = math.Log1p(math.Exp(v))
+		case "Erf":
+			y = math.Erf(v)
+		default:
+			t.Fatal("For the op type", "Expected one the evaluator supports", "Got", node.opType)
+		}
+		out.values[i] = float32(y)
+	}
+	return out
+}
+
+//*************************************************************************************************************
+//tests
+
+func TestNeuralNetworkExportONNX(t *testing.T) {
+	inputs := [][]float64{{0.4, -0.2, 0.7}, {-1.3, 0.5, 0.1}, {0.9, 0.9, -0.6}, {0, 0, 0}}
+	hiddenActFuncs := []string{actfuncs.NoActFunc, actfuncs.Step, actfuncs.Sigmoid, actfuncs.ReLU, actfuncs.LeakyReLU,
+		actfuncs.ELU, actfuncs.Tanh, actfuncs.Softplus, actfuncs.GELU, actfuncs.Swish}
+	outputActFuncs := []string{actfuncs.Softmax, actfuncs.NoActFunc, actfuncs.Sigmoid}
+	for i, actFunc := range hiddenActFuncs {
+		outputActFunc := outputActFuncs[i%len(outputActFuncs)]
+		nn, err := NewNeuralNetwork(
+			InputLayerProps{NumInputs: 3},
+			[]HiddenLayerProps{
+				HiddenLayerProps{NumNeurons: 5, ActFunc: actFunc, WeightInit: initializers.XavierNormal, BiasInit: initializers.Uniform(-0.5, 0.5)},
+				HiddenLayerProps{NumNeurons: 4, ActFunc: actFunc, ActAlpha: 0.3, WeightInit: initializers.XavierNormal, Dropout: 0.5},
+			},
+			OutputLayerProps{NumOutputs: 3, ActFunc: outputActFunc, WeightInit: initializers.XavierNormal},
+			WithSeed(int64(i)),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		err = nn.ExportONNX(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		model := decodeONNX(t, buf.Bytes())
+		if model.irVersion != onnxIRVersion || model.opsetVersion != onnxOpsetVersion {
+			t.Error("For the IR and opset versions", "Expected", onnxIRVersion, onnxOpsetVersion, "Got", model.irVersion, model.opsetVersion)
+		}
+		inputDims, outputDims := model.inputs["input"], model.outputs["output"]
+		if len(inputDims) != 2 || inputDims[0] != -1 || inputDims[1] != 3 || len(outputDims) != 2 || outputDims[0] != -1 || outputDims[1] != 3 {
+			t.Error("For the dims of the input and output", "Expected", []int64{-1, 3}, []int64{-1, 3}, "Got", inputDims, outputDims)
+		}
+		numGemms := 0
+		for _, node := range model.nodes {
+			if node.opType == "Gemm" {
+				numGemms++
+			}
+		}
+		if numGemms != 4 {
+			t.Error("For the number of Gemm nodes", "Expected", 4, "Got", numGemms)
+		}
+
+		expected, _ := nn.PredictBatch(inputs)
+		got := model.run(t, inputs)
+		if len(got.dims) != 2 || got.dims[0] != 4 || got.dims[1] != 3 {
+			t.Fatal("For the dims of the output with", actFunc, "Expected", []int64{4, 3}, "Got", got.dims)
+		}
+		for iRow := range expected {
+			for j := range expected[iRow] {
+				if math.Abs(float64(got.values[iRow*3+j])-expected[iRow][j]) > 1e-5*(1+math.Abs(expected[iRow][j])) {
+					t.Error("For the ONNX output with", actFunc, "and", outputActFunc, "Expected", expected[iRow], "Got", got.values[iRow*3:iRow*3+3])
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestNeuralNetworkExportONNXInvalid(t *testing.T) {
+	var buf bytes.Buffer
+	nn := newNormTestNeuralNetwork(t, LayerKindBatchNorm)
+	err := nn.ExportONNX(&buf)
+	if err == nil {
+		t.Error("For a BatchNorm layer, did not recieve error")
+	}
+
+	err = actfuncs.Register("onnxCustom", func(x float64) float64 { return x }, func(x float64) float64 { return 1 })
+	if err != nil {
+		t.Fatal(err)
+	}
+	nn, err = NewNeuralNetwork(
+		InputLayerProps{NumInputs: 2},
+		[]HiddenLayerProps{HiddenLayerProps{NumNeurons: 2, ActFunc: "onnxCustom"}},
+		OutputLayerProps{NumOutputs: 1, ActFunc: actfuncs.Sigmoid},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = nn.ExportONNX(&buf)
+	if err == nil {
+		t.Error("For a registered activation function, did not recieve error")
+	}
+	err = (&NeuralNetwork{}).ExportONNX(&buf)
+	if err == nil {
+		t.Error("For an invalid network, did not recieve error")
+	}
+}
@@ -0,0 +1,495 @@
+/*
+Package optimizers defines the optimizers that update the parameters of a neural network from their gradients.
+
+author: Jonas Yakimischak
+*/
+package optimizers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/jyakimischak/neuralnet/schedules"
+)
+
+// Param is a slice of parameters to be optimized with the gradients accumulated for them.  Name identifies the
+// parameters between steps so the optimizer can keep its state for them, every Param given to an optimizer must have
+// a different Name.  Decay is true for the parameters weight decay applies to, the weights but not the biases.
+type Param struct {
+	Name   string
+	Values []float64
+	Grads  []float64
+	Decay  bool
+}
+
+// Optimizer updates parameters from their gradients, keeping whatever state it needs for each Param between steps.
+type Optimizer interface {
+	// Step will apply one update to every Param using its gradients.
+	Step(params []Param) error
+	// Save will write the hyperparameters and the state of the optimizer so training can be resumed later.
+	Save(w io.Writer) error
+	// Load will restore the hyperparameters and the state written by Save.  An error is returned if they were saved
+	// by a different kind of optimizer.
+	Load(r io.Reader) error
+}
+
+// Scheduled is an Optimizer whose learning rate can follow a schedule.  Every optimizer in this package is Scheduled,
+// the schedule is queried on every step with the optimizer's LearningRate as the base rate, a nil schedule keeps the
+// learning rate constant.  The schedule is not written by Save, set it again after Load.
+type Scheduled interface {
+	Optimizer
+	LearningRateSchedule() schedules.Schedule
+}
+
+//*************************************************************************************************************
+//state
+
+// state is the step count and the per-parameter slots of an optimizer, such as its velocities or moment estimates.
+type state struct {
+	steps int
+	slots map[string][][]float64
+}
+
+// savedOptimizer is what Save writes.
+type savedOptimizer struct {
+	Optimizer       string
+	Hyperparameters json.RawMessage
+	Steps           int
+	Slots           map[string][][]float64
+}
+
+// begin will check the params and count a new step.
+func (s *state) begin(params []Param) error {
+	for _, p := range params {
+		if len(p.Values) != len(p.Grads) {
+			return fmt.Errorf("%s: len(Grads) must be %d and is: %d", p.Name, len(p.Values), len(p.Grads))
+		}
+		for i, slot := range s.slots[p.Name] {
+			if len(slot) != len(p.Values) {
+				return fmt.Errorf("%s: len(Values) must be len(slots[%d]), %d, and is: %d", p.Name, i, len(slot), len(p.Values))
+			}
+		}
+	}
+	s.steps++
+	return nil
+}
+
+// learningRate will return the learning rate for the step begun last, following the schedule if there is one.
+func (s *state) learningRate(baseRate float64, schedule schedules.Schedule) float64 {
+	if schedule == nil {
+		return baseRate
+	}
+	return schedule.LearningRate(s.steps-1, baseRate)
+}
+
+// get will return numSlots slots for the given param, each starting at 0.
+func (s *state) get(p Param, numSlots int) [][]float64 {
+	if s.slots == nil {
+		s.slots = map[string][][]float64{}
+	}
+	slots, ok := s.slots[p.Name]
+	if !ok {
+		slots = make([][]float64, numSlots)
+		for i := range slots {
+			slots[i] = make([]float64, len(p.Values))
+		}
+		s.slots[p.Name] = slots
+	}
+	return slots
+}
+
+// save will write the optimizer's name, hyperparameters and state.
+func (s *state) save(w io.Writer, name string, hyperparameters interface{}) error {
+	hp, err := json.Marshal(hyperparameters)
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(savedOptimizer{Optimizer: name, Hyperparameters: hp, Steps: s.steps, Slots: s.slots})
+}
+
+// load will read what save wrote, checking that it was written by the named optimizer.
+func (s *state) load(r io.Reader, name string, hyperparameters interface{}, numSlots int) error {
+	var saved savedOptimizer
+	err := json.NewDecoder(r).Decode(&saved)
+	if err != nil {
+		return err
+	}
+	if saved.Optimizer != name {
+		return fmt.Errorf("Saved optimizer must be %s and is: %s", name, saved.Optimizer)
+	}
+	for paramName, slots := range saved.Slots {
+		if len(slots) != numSlots {
+			return fmt.Errorf("%s: number of slots must be %d and is: %d", paramName, numSlots, len(slots))
+		}
+		for i := range slots {
+			if len(slots[i]) != len(slots[0]) {
+				return fmt.Errorf("%s: len(slots[%d]) must be %d and is: %d", paramName, i, len(slots[0]), len(slots[i]))
+			}
+		}
+	}
+	err = json.Unmarshal(saved.Hyperparameters, hyperparameters)
+	if err != nil {
+		return err
+	}
+	s.steps = saved.Steps
+	s.slots = saved.Slots
+	return nil
+}
+
+//*************************************************************************************************************
+//SGD
+
+// SGD is plain stochastic gradient descent.
+type SGD struct {
+	LearningRate float64
+	Schedule     schedules.Schedule `json:"-"`
+	state        state
+}
+
+// NewSGD will return an SGD optimizer with the given learning rate.
+func NewSGD(learningRate float64) *SGD {
+	return &SGD{LearningRate: learningRate}
+}
+
+// Step will move every parameter against its gradient.
+func (o *SGD) Step(params []Param) error {
+	err := o.state.begin(params)
+	if err != nil {
+		return err
+	}
+	learningRate := o.state.learningRate(o.LearningRate, o.Schedule)
+	for _, p := range params {
+		for i, g := range p.Grads {
+			p.Values[i] -= learningRate * g
+		}
+	}
+	return nil
+}
+
+// Save will write the optimizer's hyperparameters and state.
+func (o *SGD) Save(w io.Writer) error {
+	return o.state.save(w, "sgd", o)
+}
+
+// Load will restore the optimizer's hyperparameters and state.
+func (o *SGD) Load(r io.Reader) error {
+	return o.state.load(r, "sgd", o, 0)
+}
+
+// LearningRateSchedule will return the schedule the learning rate follows.
+func (o *SGD) LearningRateSchedule() schedules.Schedule {
+	return o.Schedule
+}
+
+//*************************************************************************************************************
+//Momentum
+
+// Momentum is gradient descent with a velocity for every parameter that keeps a fraction, Momentum, of the previous
+// update.
+type Momentum struct {
+	LearningRate float64
+	Momentum     float64
+	Schedule     schedules.Schedule `json:"-"`
+	state        state
+}
+
+// NewMomentum will return a Momentum optimizer with the given learning rate and a momentum of 0.9.
+func NewMomentum(learningRate float64) *Momentum {
+	return &Momentum{LearningRate: learningRate, Momentum: 0.9}
+}
+
+// Step will update the velocity of every parameter and move it by its velocity.
+func (o *Momentum) Step(params []Param) error {
+	err := o.state.begin(params)
+	if err != nil {
+		return err
+	}
+	learningRate := o.state.learningRate(o.LearningRate, o.Schedule)
+	for _, p := range params {
+		velocity := o.state.get(p, 1)[0]
+		for i, g := range p.Grads {
+			velocity[i] = o.Momentum*velocity[i] - learningRate*g
+			p.Values[i] += velocity[i]
+		}
+	}
+	return nil
+}
+
+// Save will write the optimizer's hyperparameters and state.
+func (o *Momentum) Save(w io.Writer) error {
+	return o.state.save(w, "momentum", o)
+}
+
+// Load will restore the optimizer's hyperparameters and state.
+func (o *Momentum) Load(r io.Reader) error {
+	return o.state.load(r, "momentum", o, 1)
+}
+
+// LearningRateSchedule will return the schedule the learning rate follows.
+func (o *Momentum) LearningRateSchedule() schedules.Schedule {
+	return o.Schedule
+}
+
+//*************************************************************************************************************
+//Nesterov
+
+// Nesterov is gradient descent with Nesterov accelerated momentum, which takes the gradient after the velocity has
+// been applied.  It is implemented so the gradients are taken at the current parameters like the other optimizers.
+type Nesterov struct {
+	LearningRate float64
+	Momentum     float64
+	Schedule     schedules.Schedule `json:"-"`
+	state        state
+}
+
+// NewNesterov will return a Nesterov optimizer with the given learning rate and a momentum of 0.9.
+func NewNesterov(learningRate float64) *Nesterov {
+	return &Nesterov{LearningRate: learningRate, Momentum: 0.9}
+}
+
+// Step will update the velocity of every parameter and move it by the look ahead update.
+func (o *Nesterov) Step(params []Param) error {
+	err := o.state.begin(params)
+	if err != nil {
+		return err
+	}
+	learningRate := o.state.learningRate(o.LearningRate, o.Schedule)
+	for _, p := range params {
+		velocity := o.state.get(p, 1)[0]
+		for i, g := range p.Grads {
+			prevVelocity := velocity[i]
+			velocity[i] = o.Momentum*velocity[i] - learningRate*g
+			p.Values[i] += -o.Momentum*prevVelocity + (1+o.Momentum)*velocity[i]
+		}
+	}
+	return nil
+}
+
+// Save will write the optimizer's hyperparameters and state.
+func (o *Nesterov) Save(w io.Writer) error {
+	return o.state.save(w, "nesterov", o)
+}
+
+// Load will restore the optimizer's hyperparameters and state.
+func (o *Nesterov) Load(r io.Reader) error {
+	return o.state.load(r, "nesterov", o, 1)
+}
+
+// LearningRateSchedule will return the schedule the learning rate follows.
+func (o *Nesterov) LearningRateSchedule() schedules.Schedule {
+	return o.Schedule
+}
+
+//*************************************************************************************************************
+//RMSProp
+
+// RMSProp divides the learning rate of every parameter by a moving average of its squared gradients, Rho being the
+// fraction of the average kept each step.
+type RMSProp struct {
+	LearningRate float64
+	Rho          float64
+	Epsilon      float64
+	Schedule     schedules.Schedule `json:"-"`
+	state        state
+}
+
+// NewRMSProp will return an RMSProp optimizer with the given learning rate, a rho of 0.9 and an epsilon of 1e-8.
+func NewRMSProp(learningRate float64) *RMSProp {
+	return &RMSProp{LearningRate: learningRate, Rho: 0.9, Epsilon: 1e-8}
+}
+
+// Step will update the average squared gradient of every parameter and move it against its scaled gradient.
+func (o *RMSProp) Step(params []Param) error {
+	err := o.state.begin(params)
+	if err != nil {
+		return err
+	}
+	learningRate := o.state.learningRate(o.LearningRate, o.Schedule)
+	for _, p := range params {
+		meanSquare := o.state.get(p, 1)[0]
+		for i, g := range p.Grads {
+			meanSquare[i] = o.Rho*meanSquare[i] + (1-o.Rho)*g*g
+			p.Values[i] -= learningRate * g / (math.Sqrt(meanSquare[i]) + o.Epsilon)
+		}
+	}
+	return nil
+}
+
+// Save will write the optimizer's hyperparameters and state.
+func (o *RMSProp) Save(w io.Writer) error {
+	return o.state.save(w, "rmsProp", o)
+}
+
+// Load will restore the optimizer's hyperparameters and state.
+func (o *RMSProp) Load(r io.Reader) error {
+	return o.state.load(r, "rmsProp", o, 1)
+}
+
+// LearningRateSchedule will return the schedule the learning rate follows.
+func (o *RMSProp) LearningRateSchedule() schedules.Schedule {
+	return o.Schedule
+}
+
+//*************************************************************************************************************
+//Adagrad
+
+// Adagrad divides the learning rate of every parameter by the root of the sum of all its squared gradients.
+type Adagrad struct {
+	LearningRate float64
+	Epsilon      float64
+	Schedule     schedules.Schedule `json:"-"`
+	state        state
+}
+
+// NewAdagrad will return an Adagrad optimizer with the given learning rate and an epsilon of 1e-8.
+func NewAdagrad(learningRate float64) *Adagrad {
+	return &Adagrad{LearningRate: learningRate, Epsilon: 1e-8}
+}
+
+// Step will add to the sum of squared gradients of every parameter and move it against its scaled gradient.
+func (o *Adagrad) Step(params []Param) error {
+	err := o.state.begin(params)
+	if err != nil {
+		return err
+	}
+	learningRate := o.state.learningRate(o.LearningRate, o.Schedule)
+	for _, p := range params {
+		sumSquares := o.state.get(p, 1)[0]
+		for i, g := range p.Grads {
+			sumSquares[i] += g * g
+			p.Values[i] -= learningRate * g / (math.Sqrt(sumSquares[i]) + o.Epsilon)
+		}
+	}
+	return nil
+}
+
+// Save will write the optimizer's hyperparameters and state.
+func (o *Adagrad) Save(w io.Writer) error {
+	return o.state.save(w, "adagrad", o)
+}
+
+// Load will restore the optimizer's hyperparameters and state.
+func (o *Adagrad) Load(r io.Reader) error {
+	return o.state.load(r, "adagrad", o, 1)
+}
+
+// LearningRateSchedule will return the schedule the learning rate follows.
+func (o *Adagrad) LearningRateSchedule() schedules.Schedule {
+	return o.Schedule
+}
+
+//*************************************************************************************************************
+//Adam
+
+// Adam keeps moving averages of the gradient and the squared gradient of every parameter, Beta1 and Beta2 being the
+// fractions of each kept every step, and moves each parameter by the bias corrected ratio of the two.
+type Adam struct {
+	LearningRate float64
+	Beta1        float64
+	Beta2        float64
+	Epsilon      float64
+	Schedule     schedules.Schedule `json:"-"`
+	state        state
+}
+
+// NewAdam will return an Adam optimizer with the given learning rate, a beta1 of 0.9, a beta2 of 0.999 and an epsilon
+// of 1e-8.
+func NewAdam(learningRate float64) *Adam {
+	return &Adam{LearningRate: learningRate, Beta1: 0.9, Beta2: 0.999, Epsilon: 1e-8}
+}
+
+// Step will update the moment estimates of every parameter and move it by the bias corrected update.
+func (o *Adam) Step(params []Param) error {
+	err := o.state.begin(params)
+	if err != nil {
+		return err
+	}
+	learningRate := o.state.learningRate(o.LearningRate, o.Schedule)
+	adamStep(&o.state, params, learningRate, o.Beta1, o.Beta2, o.Epsilon, 0)
+	return nil
+}
+
+// Save will write the optimizer's hyperparameters and state.
+func (o *Adam) Save(w io.Writer) error {
+	return o.state.save(w, "adam", o)
+}
+
+// Load will restore the optimizer's hyperparameters and state.
+func (o *Adam) Load(r io.Reader) error {
+	return o.state.load(r, "adam", o, 2)
+}
+
+// LearningRateSchedule will return the schedule the learning rate follows.
+func (o *Adam) LearningRateSchedule() schedules.Schedule {
+	return o.Schedule
+}
+
+//*************************************************************************************************************
+//AdamW
+
+// AdamW is Adam with decoupled weight decay, every step the parameters with Decay set are also moved towards 0 by
+// LearningRate * WeightDecay of their value.
+type AdamW struct {
+	LearningRate float64
+	Beta1        float64
+	Beta2        float64
+	Epsilon      float64
+	WeightDecay  float64
+	Schedule     schedules.Schedule `json:"-"`
+	state        state
+}
+
+// NewAdamW will return an AdamW optimizer with the given learning rate, a beta1 of 0.9, a beta2 of 0.999, an epsilon
+// of 1e-8 and a weight decay of 0.01.
+func NewAdamW(learningRate float64) *AdamW {
+	return &AdamW{LearningRate: learningRate, Beta1: 0.9, Beta2: 0.999, Epsilon: 1e-8, WeightDecay: 0.01}
+}
+
+// Step will update the moment estimates of every parameter and move it by the bias corrected update and the weight
+// decay.
+func (o *AdamW) Step(params []Param) error {
+	err := o.state.begin(params)
+	if err != nil {
+		return err
+	}
+	learningRate := o.state.learningRate(o.LearningRate, o.Schedule)
+	adamStep(&o.state, params, learningRate, o.Beta1, o.Beta2, o.Epsilon, o.WeightDecay)
+	return nil
+}
+
+// Save will write the optimizer's hyperparameters and state.
+func (o *AdamW) Save(w io.Writer) error {
+	return o.state.save(w, "adamW", o)
+}
+
+// Load will restore the optimizer's hyperparameters and state.
+func (o *AdamW) Load(r io.Reader) error {
+	return o.state.load(r, "adamW", o, 2)
+}
+
+// LearningRateSchedule will return the schedule the learning rate follows.
+func (o *AdamW) LearningRateSchedule() schedules.Schedule {
+	return o.Schedule
+}
+
+// adamStep will apply one Adam update to every param, with decoupled weight decay for the params with Decay set.
+func adamStep(s *state, params []Param, learningRate float64, beta1 float64, beta2 float64, epsilon float64,
+	weightDecay float64) {
+	correction1 := 1 - math.Pow(beta1, float64(s.steps))
+	correction2 := 1 - math.Pow(beta2, float64(s.steps))
+	for _, p := range params {
+		slots := s.get(p, 2)
+		m, v := slots[0], slots[1]
+		for i, g := range p.Grads {
+			m[i] = beta1*m[i] + (1-beta1)*g
+			v[i] = beta2*v[i] + (1-beta2)*g*g
+			update := (m[i] / correction1) / (math.Sqrt(v[i]/correction2) + epsilon)
+			if p.Decay {
+				update += weightDecay * p.Values[i]
+			}
+			p.Values[i] -= learningRate * update
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package optimizers
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/jyakimischak/neuralnet/schedules"
+)
+
+// newOptimizers will return one of every optimizer with hyperparameters that suit the quadratic in quadraticGrads.
+func newOptimizers() map[string]Optimizer {
+	return map[string]Optimizer{
+		"SGD":      NewSGD(0.1),
+		"Momentum": NewMomentum(0.05),
+		"Nesterov": NewNesterov(0.05),
+		"RMSProp":  NewRMSProp(0.01),
+		"Adagrad":  NewAdagrad(0.5),
+		"Adam":     NewAdam(0.05),
+		"AdamW":    NewAdamW(0.05),
+	}
+}
+
+// quadraticGrads will set the gradients of sum((values[i] - i)^2).
+func quadraticGrads(p Param) {
+	for i := range p.Values {
+		p.Grads[i] = 2 * (p.Values[i] - float64(i))
+	}
+}
+
+func TestOptimizersMinimize(t *testing.T) {
+	for name, opt := range newOptimizers() {
+		p := Param{Name: "p", Values: []float64{5, -3, 4}, Grads: make([]float64, 3)}
+		for step := 0; step < 1000; step++ {
+			quadraticGrads(p)
+			err := opt.Step([]Param{p})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		for i, v := range p.Values {
+			if math.Abs(v-float64(i)) > 0.05 {
+				t.Error("For", name, "value", i, "Expected", float64(i), "Got", v)
+			}
+		}
+	}
+}
+
+func TestOptimizersFirstStep(t *testing.T) {
+	p := Param{Name: "p", Values: []float64{1}, Grads: []float64{2}}
+	NewSGD(0.1).Step([]Param{p})
+	if math.Abs(p.Values[0]-0.8) > 1e-12 {
+		t.Error("For SGD", "Expected", 0.8, "Got", p.Values[0])
+	}
+
+	//the first two momentum steps with the same gradient: -0.2, then -0.9 * 0.2 - 0.2
+	p.Values[0] = 1
+	momentum := NewMomentum(0.1)
+	momentum.Step([]Param{p})
+	momentum.Step([]Param{p})
+	if math.Abs(p.Values[0]-(1-0.2-0.38)) > 1e-12 {
+		t.Error("For Momentum", "Expected", 1-0.2-0.38, "Got", p.Values[0])
+	}
+
+	//the first Adam step is learning rate * sign(gradient) once the bias is corrected
+	p.Values[0] = 1
+	NewAdam(0.1).Step([]Param{p})
+	if math.Abs(p.Values[0]-0.9) > 1e-6 {
+		t.Error("For Adam", "Expected", 0.9, "Got", p.Values[0])
+	}
+}
+
+func TestAdamWDecay(t *testing.T) {
+	weights := Param{Name: "weights", Values: []float64{1}, Grads: []float64{0}, Decay: true}
+	biases := Param{Name: "biases", Values: []float64{1}, Grads: []float64{0}}
+	opt := NewAdamW(0.1)
+	opt.WeightDecay = 0.5
+	err := opt.Step([]Param{weights, biases})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(weights.Values[0]-0.95) > 1e-12 {
+		t.Error("For decayed weight", "Expected", 0.95, "Got", weights.Values[0])
+	}
+	if biases.Values[0] != 1 {
+		t.Error("For bias without decay", "Expected", 1, "Got", biases.Values[0])
+	}
+}
+
+func TestOptimizersSaveLoad(t *testing.T) {
+	for name := range newOptimizers() {
+		//train two copies for 5 steps, then save one, load it into a new optimizer and train both for 5 more
+		opts := newOptimizers()
+		opt := opts[name]
+		p := Param{Name: "p", Values: []float64{5, -3, 4}, Grads: make([]float64, 3)}
+		for step := 0; step < 5; step++ {
+			quadraticGrads(p)
+			opt.Step([]Param{p})
+		}
+
+		var buf bytes.Buffer
+		err := opt.Save(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		restored := newOptimizers()[name]
+		err = restored.Load(&buf)
+		if err != nil {
+			t.Fatal(name, err)
+		}
+
+		pRestored := Param{Name: "p", Values: append([]float64{}, p.Values...), Grads: make([]float64, 3)}
+		for step := 0; step < 5; step++ {
+			quadraticGrads(p)
+			opt.Step([]Param{p})
+			quadraticGrads(pRestored)
+			restored.Step([]Param{pRestored})
+		}
+		for i := range p.Values {
+			if p.Values[i] != pRestored.Values[i] {
+				t.Error("For", name, "after Load value", i, "Expected", p.Values[i], "Got", pRestored.Values[i])
+			}
+		}
+	}
+
+	//hyperparameters are restored too
+	adam := NewAdam(0.123)
+	var buf bytes.Buffer
+	adam.Save(&buf)
+	restored := NewAdam(1)
+	restored.Load(&buf)
+	if restored.LearningRate != 0.123 {
+		t.Error("For restored LearningRate", "Expected", 0.123, "Got", restored.LearningRate)
+	}
+
+	buf.Reset()
+	NewSGD(0.1).Save(&buf)
+	err := NewAdam(0.1).Load(&buf)
+	if err == nil {
+		t.Error("For loading SGD into Adam, did not recieve error")
+	}
+
+	//every slot of a param must have the same length
+	buf.Reset()
+	buf.WriteString(`{"Optimizer":"adam","Hyperparameters":{},"Steps":1,"Slots":{"p":[[0,0,0],[0]]}}`)
+	err = NewAdam(0.1).Load(&buf)
+	if err == nil {
+		t.Error("For a short Adam slot, did not recieve error")
+	}
+	adam = NewAdam(0.1)
+	adam.state.slots = map[string][][]float64{"p": {{0, 0, 0}, {0}}}
+	err = adam.Step([]Param{{Name: "p", Values: []float64{1, 2, 3}, Grads: []float64{1, 1, 1}}})
+	if err == nil {
+		t.Error("For stepping with a short Adam slot, did not recieve error")
+	}
+}
+
+func TestOptimizersInvalidParams(t *testing.T) {
+	for name, opt := range newOptimizers() {
+		err := opt.Step([]Param{{Name: "p", Values: []float64{1, 2}, Grads: []float64{1}}})
+		if err == nil {
+			t.Error("For", name, "with mismatched Grads, did not recieve error")
+		}
+	}
+
+	opt := NewAdam(0.1)
+	opt.Step([]Param{{Name: "p", Values: []float64{1, 2}, Grads: []float64{1, 1}}})
+	err := opt.Step([]Param{{Name: "p", Values: []float64{1}, Grads: []float64{1}}})
+	if err == nil {
+		t.Error("For a param that changed size, did not recieve error")
+	}
+}
+
+func TestOptimizersSchedule(t *testing.T) {
+	for name, opt := range newOptimizers() {
+		if opt.(Scheduled).LearningRateSchedule() != nil {
+			t.Error("For", name, "LearningRateSchedule", "Expected", nil)
+		}
+	}
+
+	//the schedule is queried with the step number and LearningRate as the base rate
+	opt := NewSGD(0.1)
+	opt.Schedule = schedules.NewStepDecay(2, 0.5)
+	p := Param{Name: "p", Values: []float64{0}, Grads: []float64{1}}
+	expected := []float64{-0.1, -0.2, -0.25, -0.3, -0.325}
+	for step := range expected {
+		opt.Step([]Param{p})
+		if math.Abs(p.Values[0]-expected[step]) > 1e-12 {
+			t.Error("For SGD with StepDecay after step", step, "Expected", expected[step], "Got", p.Values[0])
+		}
+	}
+	if opt.LearningRateSchedule() != opt.Schedule {
+		t.Error("For LearningRateSchedule", "Expected", opt.Schedule, "Got", opt.LearningRateSchedule())
+	}
+
+	//the schedule is not saved but the step count it follows is
+	var buf bytes.Buffer
+	opt.Save(&buf)
+	restored := NewSGD(0.1)
+	restored.Load(&buf)
+	restored.Schedule = opt.Schedule
+	restored.Step([]Param{p})
+	if math.Abs(p.Values[0]-(-0.35)) > 1e-12 {
+		t.Error("For SGD with StepDecay after Load", "Expected", -0.35, "Got", p.Values[0])
+	}
+}
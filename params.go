@@ -0,0 +1,165 @@
+package neuralnet
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jyakimischak/neuralnet/optimizers"
+)
+
+// NumLayers will return the number of layers in the network, counting the input and output layers.  Layers are
+// numbered from 0, the input layer, to NumLayers() - 1, the output layer.
+func (nn *NeuralNetwork) NumLayers() int {
+	numLayers := 0
+	for layer := nn.InputLayer; layer != nil; layer = layer.NextLayer {
+		numLayers++
+	}
+	return numLayers
+}
+
+// layer will return the layer with the given number, 0 being the input layer.
+func (nn *NeuralNetwork) layer(iLayer int) (*neuralLayer, error) {
+	if iLayer >= 0 {
+		i := 0
+		for layer := nn.InputLayer; layer != nil; layer = layer.NextLayer {
+			if i == iLayer {
+				return layer, nil
+			}
+			i++
+		}
+	}
+	return nil, fmt.Errorf("iLayer must be >= 0 and < %d and is: %d", nn.NumLayers(), iLayer)
+}
+
+// neuronLayer will return the layer with the given number after checking that it has the given neuron.
+func (nn *NeuralNetwork) neuronLayer(iLayer int, iNeuron int) (*neuralLayer, error) {
+	layer, err := nn.layer(iLayer)
+	if err != nil {
+		return nil, err
+	}
+	if iNeuron < 0 || iNeuron >= layer.NumNeurons {
+		return nil, fmt.Errorf("iNeuron must be >= 0 and < %d and is: %d", layer.NumNeurons, iNeuron)
+	}
+	if layer.Kind != LayerKindDense {
+		return nil, fmt.Errorf("layer %d is a %s layer, it has no weights or biases", iLayer, layer.Kind)
+	}
+	return layer, nil
+}
+
+// Bias will return the bias of a neuron.
+func (nn *NeuralNetwork) Bias(iLayer int, iNeuron int) (float64, error) {
+	layer, err := nn.neuronLayer(iLayer, iNeuron)
+	if err != nil {
+		return 0, err
+	}
+	return layer.Biases[iNeuron], nil
+}
+
+// SetBias will set the bias of a neuron.
+func (nn *NeuralNetwork) SetBias(iLayer int, iNeuron int, bias float64) error {
+	layer, err := nn.neuronLayer(iLayer, iNeuron)
+	if err != nil {
+		return err
+	}
+	layer.Biases[iNeuron] = bias
+	return nil
+}
+
+// Weights will return a copy of the weights of a neuron, one for each input of its layer.
+func (nn *NeuralNetwork) Weights(iLayer int, iNeuron int) ([]float64, error) {
+	layer, err := nn.neuronLayer(iLayer, iNeuron)
+	if err != nil {
+		return nil, err
+	}
+	return append([]float64(nil), layer.Weights[iNeuron*layer.NumInputs:(iNeuron+1)*layer.NumInputs]...), nil
+}
+
+// SetWeights will set the weights of a neuron, there must be one for each input of its layer.
+func (nn *NeuralNetwork) SetWeights(iLayer int, iNeuron int, weights []float64) error {
+	layer, err := nn.neuronLayer(iLayer, iNeuron)
+	if err != nil {
+		return err
+	}
+	if len(weights) != layer.NumInputs {
+		return fmt.Errorf("len(weights) must be %d and is: %d", layer.NumInputs, len(weights))
+	}
+	copy(layer.Weights[iNeuron*layer.NumInputs:(iNeuron+1)*layer.NumInputs], weights)
+	return nil
+}
+
+// Params will return the weights and biases of every layer, with their accumulated gradients, for an optimizer.  They
+// are named "layerN.weights" and "layerN.biases", N being the layer number, or "layerN.gamma" and "layerN.beta" for a
+// normalization layer, and share memory with the network so an optimizer step changes the network directly.  Weight
+// decay applies to the weights but not the biases, Gamma or Beta.
+func (nn *NeuralNetwork) Params() []optimizers.Param {
+	var params []optimizers.Param
+	iLayer := 0
+	for layer := nn.InputLayer; layer != nil; layer = layer.NextLayer {
+		if layer.Kind == LayerKindDense {
+			params = append(params,
+				optimizers.Param{Name: fmt.Sprintf("layer%d.weights", iLayer), Values: layer.Weights, Grads: layer.WeightGrads, Decay: true},
+				optimizers.Param{Name: fmt.Sprintf("layer%d.biases", iLayer), Values: layer.Biases, Grads: layer.BiasGrads},
+			)
+		} else {
+			params = append(params,
+				optimizers.Param{Name: fmt.Sprintf("layer%d.gamma", iLayer), Values: layer.Gamma, Grads: layer.GammaGrads},
+				optimizers.Param{Name: fmt.Sprintf("layer%d.beta", iLayer), Values: layer.Beta, Grads: layer.BetaGrads},
+			)
+		}
+		iLayer++
+	}
+	return params
+}
+
+// state will return every slice of values that makes up the trained state of the layer: the weights and biases, and
+// Gamma, Beta and the running statistics for a normalization layer.  Slices the layer does not have are nil.
+func (nl *neuralLayer) state() [][]float64 {
+	return [][]float64{nl.Weights, nl.Biases, nl.Gamma, nl.Beta, nl.RunningMean, nl.RunningVar}
+}
+
+// Snapshot is a copy of the trained state of every layer of a network, see NeuralNetwork.Snapshot.
+type Snapshot struct {
+	layers [][][]float64
+}
+
+// Snapshot will return a copy of the weights and biases of every layer, and the scale, shift and running statistics
+// of normalization layers, which can be put back with Restore.
+func (nn *NeuralNetwork) Snapshot() *Snapshot {
+	s := &Snapshot{}
+	for layer := nn.InputLayer; layer != nil; layer = layer.NextLayer {
+		var layerState [][]float64
+		for _, values := range layer.state() {
+			layerState = append(layerState, append([]float64(nil), values...))
+		}
+		s.layers = append(s.layers, layerState)
+	}
+	return s
+}
+
+// Restore will set the trained state of every layer to that in the snapshot, which must have been taken from a
+// network of the same shape.
+func (nn *NeuralNetwork) Restore(s *Snapshot) error {
+	if s == nil {
+		return errors.New("snapshot must not be nil")
+	}
+	if len(s.layers) != nn.NumLayers() {
+		return fmt.Errorf("snapshot must have %d layers and has: %d", nn.NumLayers(), len(s.layers))
+	}
+	iLayer := 0
+	for layer := nn.InputLayer; layer != nil; layer = layer.NextLayer {
+		for i, values := range layer.state() {
+			if len(s.layers[iLayer][i]) != len(values) {
+				return fmt.Errorf("snapshot layer %d does not match the shape of the network", iLayer)
+			}
+		}
+		iLayer++
+	}
+	iLayer = 0
+	for layer := nn.InputLayer; layer != nil; layer = layer.NextLayer {
+		for i, values := range layer.state() {
+			copy(values, s.layers[iLayer][i])
+		}
+		iLayer++
+	}
+	return nil
+}
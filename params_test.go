@@ -0,0 +1,161 @@
+package neuralnet
+
+import (
+	"testing"
+
+	"github.com/jyakimischak/neuralnet/actfuncs"
+	"github.com/jyakimischak/neuralnet/initializers"
+)
+
+func TestNeuralNetworkParams(t *testing.T) {
+	nn, err := NewNeuralNetwork(
+		InputLayerProps{NumInputs: 3},
+		[]HiddenLayerProps{
+			HiddenLayerProps{NumNeurons: 2, ActFunc: actfuncs.NoActFunc, BiasInit: initializers.Constant(0.1)},
+		},
+		OutputLayerProps{NumOutputs: 1, ActFunc: actfuncs.NoActFunc},
+		WithSeed(1),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if nn.NumLayers() != 3 {
+		t.Error("For nn.NumLayers()", "Expected", 3, "Got", nn.NumLayers())
+	}
+
+	//biases start at 0 unless an initializer is given
+	bias, err := nn.Bias(1, 1)
+	if err != nil || bias != 0.1 {
+		t.Error("For BiasInit Constant(0.1)", "Expected", 0.1, "Got", bias, err)
+	}
+	bias, err = nn.Bias(2, 0)
+	if err != nil || bias != 0 {
+		t.Error("For default bias", "Expected", 0, "Got", bias, err)
+	}
+
+	_, err = nn.Bias(3, 0)
+	if err == nil {
+		t.Error("For layer 3, did not recieve error")
+	}
+	_, err = nn.Bias(-1, 0)
+	if err == nil {
+		t.Error("For layer -1, did not recieve error")
+	}
+	err = nn.SetBias(1, 2, 1)
+	if err == nil {
+		t.Error("For neuron 2 of a 2 neuron layer, did not recieve error")
+	}
+	err = nn.SetWeights(1, 0, []float64{1})
+	if err == nil {
+		t.Error("For the wrong number of weights, did not recieve error")
+	}
+
+	//make the network calculate (x0 + x1 + x2) * 3 + 7 with a known bias in every layer
+	for iNeuron := 0; iNeuron < 3; iNeuron++ {
+		weights := []float64{0, 0, 0}
+		weights[iNeuron] = 1
+		nn.SetWeights(0, iNeuron, weights)
+		nn.SetBias(0, iNeuron, 0)
+	}
+	nn.SetWeights(1, 0, []float64{1, 1, 1})
+	nn.SetBias(1, 0, 2)
+	nn.SetWeights(1, 1, []float64{2, 2, 2})
+	nn.SetBias(1, 1, -1)
+	nn.SetWeights(2, 0, []float64{1, 1})
+	err = nn.SetBias(2, 0, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outputs, err := nn.Predict([]float64{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outputs[0] != 6*3+7 {
+		t.Error("For outputs[0]", "Expected", 6*3+7, "Got", outputs[0])
+	}
+
+	weights, err := nn.Weights(1, 1)
+	if err != nil || len(weights) != 3 || weights[0] != 2 {
+		t.Error("For nn.Weights(1, 1)", "Expected", []float64{2, 2, 2}, "Got", weights, err)
+	}
+	weights[0] = 100
+	weights2, _ := nn.Weights(1, 1)
+	if weights2[0] != 2 {
+		t.Error("For nn.Weights(1, 1) after changing the returned slice", "Expected", 2, "Got", weights2[0])
+	}
+
+	//the bias is trained with the weights
+	nn.Train([]float64{1, 2, 3}, []float64{0}, 0.01)
+	bias, _ = nn.Bias(2, 0)
+	if bias >= 6 {
+		t.Error("For the output bias after training towards a lower output", "Expected less than", 6, "Got", bias)
+	}
+}
+
+func TestNeuralNetworkParamsForOptimizer(t *testing.T) {
+	nn, err := NewNeuralNetwork(
+		InputLayerProps{NumInputs: 3},
+		[]HiddenLayerProps{
+			HiddenLayerProps{NumNeurons: 2, ActFunc: actfuncs.NoActFunc},
+		},
+		OutputLayerProps{NumOutputs: 1, ActFunc: actfuncs.NoActFunc},
+		WithSeed(1),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	params := nn.Params()
+	if len(params) != 6 {
+		t.Fatal("For len(nn.Params())", "Expected", 6, "Got", len(params))
+	}
+	if params[2].Name != "layer1.weights" || !params[2].Decay || len(params[2].Values) != 6 {
+		t.Error("For params[2]", "Expected layer1.weights with 6 decayed values", "Got", params[2].Name, len(params[2].Values), params[2].Decay)
+	}
+	if params[5].Name != "layer2.biases" || params[5].Decay || len(params[5].Values) != 1 {
+		t.Error("For params[5]", "Expected layer2.biases with 1 value and no decay", "Got", params[5].Name, len(params[5].Values), params[5].Decay)
+	}
+
+	//the params share memory with the network
+	params[5].Values[0] = 3
+	bias, _ := nn.Bias(2, 0)
+	if bias != 3 {
+		t.Error("For the bias after changing params[5]", "Expected", 3, "Got", bias)
+	}
+}
+
+func TestNeuralNetworkSnapshot(t *testing.T) {
+	nn := newSeedTestNeuralNetwork(t, WithSeed(1))
+	snapshot := nn.Snapshot()
+	before, _ := nn.Predict([]float64{0.5, 0.5, 0.5})
+
+	nn.SetBias(1, 0, 100)
+	nn.SetWeights(2, 0, make([]float64, nn.OutputLayer.NumInputs))
+	changed, _ := nn.Predict([]float64{0.5, 0.5, 0.5})
+	if changed[0] == before[0] {
+		t.Fatal("For outputs after changing the network", "Expected them to change from", before[0])
+	}
+
+	err := nn.Restore(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, _ := nn.Predict([]float64{0.5, 0.5, 0.5})
+	for i := range before {
+		if after[i] != before[i] {
+			t.Error("For outputs after Restore", "Expected", before[i], "Got", after[i])
+		}
+	}
+
+	other, _ := NewNeuralNetwork(InputLayerProps{NumInputs: 1}, nil, OutputLayerProps{NumOutputs: 1, ActFunc: actfuncs.NoActFunc})
+	err = other.Restore(snapshot)
+	if err == nil {
+		t.Error("For restoring into a network of another shape, did not recieve error")
+	}
+	err = nn.Restore(nil)
+	if err == nil {
+		t.Error("For restoring nil, did not recieve error")
+	}
+}
@@ -0,0 +1,165 @@
+package neuralnet
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// Predict will run the given inputs through the network and return the outputs.  The outputs are a new slice that is
+// not shared with the network, so it is not changed by later calls.
+//
+// Predict only reads the network's weights and biases, the activations are kept in an InferenceContext, so it is safe
+// to call from many goroutines at once as long as the network is not being trained at the same time.
+func (nn *NeuralNetwork) Predict(inputs []float64) ([]float64, error) {
+	ctx, ok := nn.contextPool.Get().(*InferenceContext)
+	if !ok || ctx.nn != nn {
+		ctx = nn.NewInferenceContext()
+	}
+	outputs, err := ctx.Predict(inputs)
+	nn.contextPool.Put(ctx)
+	return outputs, err
+}
+
+// PredictBatch will run every row of inputs through the network and return the outputs for each row, in the same
+// order.  The rows are split between BatchWorkers goroutines.  Each goroutine uses its own InferenceContext so the
+// network is only read from, never written to.
+func (nn *NeuralNetwork) PredictBatch(inputs [][]float64) ([][]float64, error) {
+	for iRow := 0; iRow < len(inputs); iRow++ {
+		err := nn.checkInputs(inputs[iRow])
+		if err != nil {
+			return nil, fmt.Errorf("inputs[%d]: %v", iRow, err)
+		}
+	}
+	isValid, invalidMsg := nn.IsValid()
+	if !isValid {
+		return nil, errors.New(invalidMsg)
+	}
+
+	numWorkers := nn.BatchWorkers
+	if numWorkers < 1 {
+		numWorkers = runtime.GOMAXPROCS(0)
+	}
+	if numWorkers > len(inputs) {
+		numWorkers = len(inputs)
+	}
+
+	outputs := make([][]float64, len(inputs))
+	var wg sync.WaitGroup
+	for iWorker := 0; iWorker < numWorkers; iWorker++ {
+		//each worker takes a contiguous block of rows and runs it through the network predictBlockSize rows at a time
+		start := iWorker * len(inputs) / numWorkers
+		end := (iWorker + 1) * len(inputs) / numWorkers
+		wg.Add(1)
+		go func(start int, end int) {
+			defer wg.Done()
+			ctx := nn.NewInferenceContext()
+			for blockStart := start; blockStart < end; blockStart += predictBlockSize {
+				blockEnd := blockStart + predictBlockSize
+				if blockEnd > end {
+					blockEnd = end
+				}
+				ctx.predictBlock(inputs[blockStart:blockEnd], outputs[blockStart:blockEnd])
+			}
+		}(start, end)
+	}
+	wg.Wait()
+
+	return outputs, nil
+}
+
+// checkInputs will return an error if the network was not created with NewNeuralNetwork or if the number of inputs
+// does not match the input layer.
+func (nn *NeuralNetwork) checkInputs(inputs []float64) error {
+	if nn.InputLayer == nil || nn.OutputLayer == nil {
+		return errors.New("Invalid neural network. Did you call NewNeuralNetwork when getting the instance?")
+	}
+	if len(inputs) != nn.InputLayer.NumInputs {
+		return fmt.Errorf("len(inputs) must be %d and is: %d", nn.InputLayer.NumInputs, len(inputs))
+	}
+	return nil
+}
+
+//*************************************************************************************************************
+//InferenceContext
+
+// predictBlockSize is the number of rows PredictBatch runs through each layer at once.
+const predictBlockSize = 64
+
+// InferenceContext holds the activations of every layer for running a network, so the network itself only holds its
+// parameters and is never written to by inference.  One network can serve many goroutines at once by giving each of
+// them its own InferenceContext.  An InferenceContext is not safe for concurrent use.
+type InferenceContext struct {
+	nn           *NeuralNetwork
+	layerOutputs [][]float64
+	//blockInputs and blockOutputs hold predictBlockSize rows each, they are only allocated when PredictBatch needs them
+	blockInputs  []float64
+	blockOutputs [][]float64
+}
+
+// NewInferenceContext will return an InferenceContext with activation buffers sized for every layer in this network.
+func (nn *NeuralNetwork) NewInferenceContext() *InferenceContext {
+	ctx := &InferenceContext{nn: nn}
+	for layer := nn.InputLayer; layer != nil; layer = layer.NextLayer {
+		ctx.layerOutputs = append(ctx.layerOutputs, make([]float64, layer.NumNeurons))
+	}
+	return ctx
+}
+
+// Predict will run the given inputs through the context's network and return the outputs in a new slice.
+func (ctx *InferenceContext) Predict(inputs []float64) ([]float64, error) {
+	err := ctx.nn.checkInputs(inputs)
+	if err != nil {
+		return nil, err
+	}
+	isValid, invalidMsg := ctx.nn.IsValid()
+	if !isValid {
+		return nil, errors.New(invalidMsg)
+	}
+	if len(ctx.layerOutputs) != len(ctx.nn.HiddenLayers)+2 {
+		return nil, errors.New("InferenceContext does not match the network. Did you call NewInferenceContext when getting the instance?")
+	}
+	return append([]float64(nil), ctx.forward(inputs, 1, ctx.layerOutputs)...), nil
+}
+
+// predictBlock will run up to predictBlockSize rows of inputs through the network together, using the matrix-matrix
+// kernel, and store a new slice of outputs for each row in outputs.
+func (ctx *InferenceContext) predictBlock(inputs [][]float64, outputs [][]float64) {
+	if ctx.blockOutputs == nil {
+		ctx.blockInputs = make([]float64, predictBlockSize*ctx.nn.InputLayer.NumInputs)
+		for layer := ctx.nn.InputLayer; layer != nil; layer = layer.NextLayer {
+			ctx.blockOutputs = append(ctx.blockOutputs, make([]float64, predictBlockSize*layer.NumNeurons))
+		}
+	}
+
+	numInputs := ctx.nn.InputLayer.NumInputs
+	for iRow := range inputs {
+		copy(ctx.blockInputs[iRow*numInputs:(iRow+1)*numInputs], inputs[iRow])
+	}
+	blockOutputs := ctx.forward(ctx.blockInputs, len(inputs), ctx.blockOutputs)
+	numOutputs := ctx.nn.OutputLayer.NumNeurons
+	for iRow := range outputs {
+		outputs[iRow] = append([]float64(nil), blockOutputs[iRow*numOutputs:(iRow+1)*numOutputs]...)
+	}
+}
+
+// forward will run numRows rows of inputs, stored one after the other, through every layer using the given buffers,
+// one per layer, and return the outputs of the output layer, which are only valid until the buffers are used again.
+// The network must be valid.
+func (ctx *InferenceContext) forward(inputs []float64, numRows int, buffers [][]float64) []float64 {
+	layerInputs := inputs
+	iLayer := 0
+	for layer := ctx.nn.InputLayer; layer != nil; layer = layer.NextLayer {
+		layerOutputs := buffers[iLayer][:numRows*layer.NumNeurons]
+		if layer.Kind == LayerKindDense {
+			layer.weightedSums(layerInputs, numRows, layerOutputs)
+		} else {
+			layer.normalize(layerInputs, numRows, false, nil, nil, layerOutputs)
+		}
+		layer.applyActFunc(layerOutputs, numRows, layerOutputs)
+		layerInputs = layerOutputs
+		iLayer++
+	}
+	return layerInputs
+}
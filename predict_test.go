@@ -0,0 +1,260 @@
+package neuralnet
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/jyakimischak/neuralnet/actfuncs"
+)
+
+func TestPredict(t *testing.T) {
+	nn, err := NewNeuralNetwork(
+		InputLayerProps{NumInputs: 3},
+		[]HiddenLayerProps{
+			HiddenLayerProps{NumNeurons: 4, ActFunc: actfuncs.Sigmoid},
+		},
+		OutputLayerProps{NumOutputs: 2, ActFunc: actfuncs.NoActFunc},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = nn.Predict([]float64{1, 2})
+	if err == nil {
+		t.Error("For wrong number of inputs, did not recieve error")
+	}
+	_, err = (&NeuralNetwork{}).Predict([]float64{1, 2, 3})
+	if err == nil {
+		t.Error("For uninitialized neural network, did not recieve error")
+	}
+
+	inputs := []float64{0.1, 0.2, 0.3}
+	outputs, err := nn.Predict(inputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outputs) != 2 {
+		t.Fatal("For len(outputs)", "Expected", 2, "Got", len(outputs))
+	}
+
+	//must match running the layers by hand
+	copy(nn.InputLayer.Inputs, inputs)
+	nn.Calc()
+	for i := 0; i < 2; i++ {
+		if outputs[i] != nn.OutputLayer.Outputs[i] {
+			t.Errorf("For outputs[%d] Expected %f Got %f", i, nn.OutputLayer.Outputs[i], outputs[i])
+		}
+	}
+
+	//the returned outputs must not be overwritten by the next call
+	first := outputs[0]
+	_, err = nn.Predict([]float64{5, -5, 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outputs[0] != first {
+		t.Error("For outputs[0] after next Predict", "Expected", first, "Got", outputs[0])
+	}
+	outputs[1] = 1000
+	if nn.OutputLayer.Outputs[1] == 1000 {
+		t.Error("For nn.OutputLayer.Outputs[1]", "Expected it to not share memory with outputs")
+	}
+}
+
+func TestPredictBatch(t *testing.T) {
+	nn, err := NewNeuralNetwork(
+		InputLayerProps{NumInputs: 3},
+		[]HiddenLayerProps{
+			HiddenLayerProps{NumNeurons: 5, ActFunc: actfuncs.ReLU},
+			HiddenLayerProps{NumNeurons: 4, ActFunc: actfuncs.Sigmoid},
+		},
+		OutputLayerProps{NumOutputs: 3, ActFunc: actfuncs.Softmax},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = nn.PredictBatch([][]float64{{1, 2, 3}, {1, 2}})
+	if err == nil {
+		t.Error("For a row with the wrong number of inputs, did not recieve error")
+	}
+
+	empty, err := nn.PredictBatch(nil)
+	if err != nil || len(empty) != 0 {
+		t.Error("For empty batch", "Expected", 0, "rows and no error", "Got", len(empty), err)
+	}
+
+	inputs := make([][]float64, 101)
+	for i := range inputs {
+		inputs[i] = []float64{float64(i) / 100, float64(i%7) - 3, -float64(i) / 50}
+	}
+	expected := make([][]float64, len(inputs))
+	for i := range inputs {
+		expected[i], err = nn.Predict(inputs[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	stateBefore := append([]float64{}, nn.OutputLayer.Outputs...)
+
+	for _, numWorkers := range []int{0, 1, 3, 8, 500} {
+		nn.BatchWorkers = numWorkers
+		outputs, err := nn.PredictBatch(inputs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(outputs) != len(inputs) {
+			t.Fatal("For len(outputs) with", numWorkers, "workers", "Expected", len(inputs), "Got", len(outputs))
+		}
+		for i := range outputs {
+			for j := range outputs[i] {
+				if outputs[i][j] != expected[i][j] {
+					t.Errorf("For %d workers outputs[%d][%d] Expected %f Got %f", numWorkers, i, j, expected[i][j], outputs[i][j])
+				}
+			}
+		}
+	}
+
+	//the layers are never written to
+	for i := range stateBefore {
+		if nn.OutputLayer.Outputs[i] != stateBefore[i] {
+			t.Errorf("For nn.OutputLayer.Outputs[%d] Expected %f Got %f", i, stateBefore[i], nn.OutputLayer.Outputs[i])
+		}
+	}
+}
+
+func TestPredictConcurrent(t *testing.T) {
+	nn, err := NewNeuralNetwork(
+		InputLayerProps{NumInputs: 4},
+		[]HiddenLayerProps{
+			HiddenLayerProps{NumNeurons: 8, ActFunc: actfuncs.Tanh},
+		},
+		OutputLayerProps{NumOutputs: 3, ActFunc: actfuncs.Softmax},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inputs := make([][]float64, 50)
+	expected := make([][]float64, len(inputs))
+	for i := range inputs {
+		inputs[i] = []float64{float64(i), -float64(i) / 10, 0.5, float64(i%3) - 1}
+		expected[i], err = nn.NewInferenceContext().Predict(inputs[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	//run with -race to check that concurrent inference does not share any state
+	const numGoroutines = 16
+	var wg sync.WaitGroup
+	errs := make(chan string, numGoroutines*len(inputs))
+	for g := 0; g < numGoroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			ctx := nn.NewInferenceContext()
+			for i := range inputs {
+				//alternate the row order so the goroutines are working on different inputs at the same time
+				iRow := (i + g) % len(inputs)
+				var outputs []float64
+				var err error
+				switch g % 3 {
+				case 0:
+					outputs, err = nn.Predict(inputs[iRow])
+				case 1:
+					outputs, err = ctx.Predict(inputs[iRow])
+				default:
+					var batch [][]float64
+					batch, err = nn.PredictBatch(inputs[iRow : iRow+1])
+					if err == nil {
+						outputs = batch[0]
+					}
+				}
+				if err != nil {
+					errs <- err.Error()
+					continue
+				}
+				for j := range outputs {
+					if outputs[j] != expected[iRow][j] {
+						errs <- fmt.Sprintf("For goroutine %d outputs[%d][%d] Expected %f Got %f", g, iRow, j, expected[iRow][j], outputs[j])
+					}
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+	for msg := range errs {
+		t.Error(msg)
+	}
+}
+
+func TestInferenceContext(t *testing.T) {
+	nn, err := NewNeuralNetwork(
+		InputLayerProps{NumInputs: 2},
+		nil,
+		OutputLayerProps{NumOutputs: 1, ActFunc: actfuncs.Sigmoid},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewNeuralNetwork(
+		InputLayerProps{NumInputs: 2},
+		[]HiddenLayerProps{
+			HiddenLayerProps{NumNeurons: 3, ActFunc: actfuncs.Sigmoid},
+		},
+		OutputLayerProps{NumOutputs: 1, ActFunc: actfuncs.Sigmoid},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := nn.NewInferenceContext()
+	_, err = ctx.Predict([]float64{1})
+	if err == nil {
+		t.Error("For wrong number of inputs, did not recieve error")
+	}
+	_, err = (&InferenceContext{nn: other}).Predict([]float64{1, 2})
+	if err == nil {
+		t.Error("For InferenceContext not from NewInferenceContext, did not recieve error")
+	}
+
+	//inference does not touch the state Calc leaves for training
+	copy(nn.InputLayer.Inputs, []float64{3, 4})
+	nn.Calc()
+	before := nn.OutputLayer.Outputs[0]
+	outputs, err := ctx.Predict([]float64{-3, -4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nn.OutputLayer.Outputs[0] != before || nn.InputLayer.Inputs[0] != 3 {
+		t.Error("For layer state after InferenceContext.Predict", "Expected", before, 3, "Got", nn.OutputLayer.Outputs[0], nn.InputLayer.Inputs[0])
+	}
+	if outputs[0] == before {
+		t.Error("For outputs[0]", "Expected a different value than", before)
+	}
+}
+
+func BenchmarkPredict(b *testing.B) {
+	nn := newBenchmarkNeuralNetwork(b)
+	inputs := make([]float64, 128)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		nn.Predict(inputs)
+	}
+}
+
+func BenchmarkPredictBatch(b *testing.B) {
+	nn := newBenchmarkNeuralNetwork(b)
+	nn.BatchWorkers = 1
+	inputs := make([][]float64, 256)
+	for i := range inputs {
+		inputs[i] = make([]float64, 128)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		nn.PredictBatch(inputs)
+	}
+}
@@ -0,0 +1,73 @@
+package neuralnet
+
+import "math"
+
+//*************************************************************************************************************
+//neuralLayer
+
+// regularizationLoss will return the L1 and L2 penalty on this layer's weights, and its biases if RegularizeBiases
+// is true.
+func (nl *neuralLayer) regularizationLoss() float64 {
+	if nl.L1 == 0 && nl.L2 == 0 {
+		return 0
+	}
+	loss := penalty(nl.Weights, nl.L1, nl.L2)
+	if nl.RegularizeBiases {
+		loss += penalty(nl.Biases, nl.L1, nl.L2)
+	}
+	return loss
+}
+
+// backwardRegularization will add the gradient of this layer's L1 and L2 penalty to its accumulated gradients.
+func (nl *neuralLayer) backwardRegularization() {
+	if nl.L1 == 0 && nl.L2 == 0 {
+		return
+	}
+	penaltyGrads(nl.Weights, nl.L1, nl.L2, nl.WeightGrads)
+	if nl.RegularizeBiases {
+		penaltyGrads(nl.Biases, nl.L1, nl.L2, nl.BiasGrads)
+	}
+}
+
+// penalty will return l1 * sum(|p|) + l2 * sum(p^2).
+func penalty(params []float64, l1 float64, l2 float64) float64 {
+	sumAbs, sumSquares := 0.0, 0.0
+	for _, p := range params {
+		sumAbs += math.Abs(p)
+		sumSquares += p * p
+	}
+	return l1*sumAbs + l2*sumSquares
+}
+
+// penaltyGrads will add the gradient of l1 * sum(|p|) + l2 * sum(p^2) to grads, taking the gradient of |p| as 0 at 0.
+func penaltyGrads(params []float64, l1 float64, l2 float64, grads []float64) {
+	for i, p := range params {
+		switch {
+		case p > 0:
+			grads[i] += l1
+		case p < 0:
+			grads[i] -= l1
+		}
+		grads[i] += 2 * l2 * p
+	}
+}
+
+//*************************************************************************************************************
+//NeuralNetwork
+
+// RegularizationLoss will return the sum of the L1 and L2 penalties of every layer, which training adds to the loss.
+func (nn *NeuralNetwork) RegularizationLoss() float64 {
+	loss := 0.0
+	for layer := nn.InputLayer; layer != nil; layer = layer.NextLayer {
+		loss += layer.regularizationLoss()
+	}
+	return loss
+}
+
+// BackwardRegularization will add the gradient of RegularizationLoss to the accumulated gradients of every layer.
+// Fit does this once per batch, code that calls Backward directly should call it once before each update.
+func (nn *NeuralNetwork) BackwardRegularization() {
+	for layer := nn.InputLayer; layer != nil; layer = layer.NextLayer {
+		layer.backwardRegularization()
+	}
+}
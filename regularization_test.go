@@ -0,0 +1,139 @@
+package neuralnet
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jyakimischak/neuralnet/actfuncs"
+	"github.com/jyakimischak/neuralnet/initializers"
+	"github.com/jyakimischak/neuralnet/losses"
+	"github.com/jyakimischak/neuralnet/optimizers"
+)
+
+// newRegularizedNeuralNetwork will return a seeded network with the given L1 and L2 penalties on the hidden and
+// output layers.
+func newRegularizedNeuralNetwork(t *testing.T, l1 float64, l2 float64, regularizeBiases bool) *NeuralNetwork {
+	nn, err := NewNeuralNetwork(
+		InputLayerProps{NumInputs: 2},
+		[]HiddenLayerProps{
+			HiddenLayerProps{NumNeurons: 3, ActFunc: actfuncs.Tanh, L1: l1, L2: l2, RegularizeBiases: regularizeBiases,
+				BiasInit: initializers.Constant(0.5)},
+		},
+		OutputLayerProps{NumOutputs: 1, ActFunc: actfuncs.Sigmoid, L1: l1, L2: l2, RegularizeBiases: regularizeBiases,
+			BiasInit: initializers.Constant(0.5)},
+		WithSeed(1),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return nn
+}
+
+func TestRegularizationLoss(t *testing.T) {
+	nn := newRegularizedNeuralNetwork(t, 0.1, 0.2, false)
+	expected := 0.0
+	for _, layer := range append(nn.HiddenLayers, nn.OutputLayer) {
+		for _, w := range layer.Weights {
+			expected += 0.1*math.Abs(w) + 0.2*w*w
+		}
+	}
+	if math.Abs(nn.RegularizationLoss()-expected) > 1e-12 {
+		t.Error("For RegularizationLoss without biases", "Expected", expected, "Got", nn.RegularizationLoss())
+	}
+
+	//4 biases of 0.5
+	nn = newRegularizedNeuralNetwork(t, 0.1, 0.2, true)
+	expected += 4 * (0.1*0.5 + 0.2*0.25)
+	if math.Abs(nn.RegularizationLoss()-expected) > 1e-12 {
+		t.Error("For RegularizationLoss with biases", "Expected", expected, "Got", nn.RegularizationLoss())
+	}
+
+	nn = newRegularizedNeuralNetwork(t, 0, 0, true)
+	if nn.RegularizationLoss() != 0 {
+		t.Error("For RegularizationLoss without penalties", "Expected", 0, "Got", nn.RegularizationLoss())
+	}
+
+	_, err := NewNeuralNetwork(
+		InputLayerProps{NumInputs: 2},
+		[]HiddenLayerProps{HiddenLayerProps{NumNeurons: 3, ActFunc: actfuncs.Tanh, L1: -1}},
+		OutputLayerProps{NumOutputs: 1, ActFunc: actfuncs.Sigmoid},
+	)
+	if err == nil {
+		t.Error("For a negative L1, did not recieve error")
+	}
+	_, err = NewNeuralNetwork(
+		InputLayerProps{NumInputs: 2},
+		nil,
+		OutputLayerProps{NumOutputs: 1, ActFunc: actfuncs.Sigmoid, L2: -1},
+	)
+	if err == nil {
+		t.Error("For a negative L2, did not recieve error")
+	}
+}
+
+func TestBackwardRegularization(t *testing.T) {
+	for _, regularizeBiases := range []bool{false, true} {
+		nn := newRegularizedNeuralNetwork(t, 0.1, 0.2, regularizeBiases)
+		inputs := []float64{0.4, -0.2}
+		targets := []float64{1}
+		lossFn := func() float64 {
+			copy(nn.InputLayer.Inputs, inputs)
+			nn.Calc()
+			loss, _ := nn.Loss(losses.BinaryCrossEntropy, targets)
+			return loss + nn.RegularizationLoss()
+		}
+
+		lossFn()
+		nn.ZeroGrads()
+		nn.BackwardLoss(losses.BinaryCrossEntropy, targets)
+		nn.BackwardRegularization()
+		checkGradients(t, nn, lossFn)
+	}
+}
+
+func TestFitRegularization(t *testing.T) {
+	//train the same network with and without an L2 penalty, the penalty must keep the weights smaller
+	sumSquares := func(nn *NeuralNetwork) float64 {
+		sum := 0.0
+		for _, layer := range append(nn.HiddenLayers, nn.OutputLayer) {
+			for _, w := range layer.Weights {
+				sum += w * w
+			}
+		}
+		return sum
+	}
+	config := FitConfig{Epochs: 200, Loss: losses.BinaryCrossEntropy, Optimizer: optimizers.NewAdam(0.05)}
+
+	plain := newRegularizedNeuralNetwork(t, 0, 0, false)
+	history, err := plain.Fit(newXORDataset(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if history[199].RegularizationLoss != 0 {
+		t.Error("For RegularizationLoss without penalties", "Expected", 0, "Got", history[199].RegularizationLoss)
+	}
+
+	regularized := newRegularizedNeuralNetwork(t, 0, 0.05, false)
+	var lastBatch BatchStats
+	config.Optimizer = optimizers.NewAdam(0.05)
+	config.OnBatchEnd = func(stats BatchStats) { lastBatch = stats }
+	history, err = regularized.Fit(newXORDataset(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if history[199].RegularizationLoss <= 0 || lastBatch.RegularizationLoss != history[199].RegularizationLoss {
+		t.Error("For RegularizationLoss with L2", "Expected", lastBatch.RegularizationLoss, "Got", history[199].RegularizationLoss)
+	}
+	if sumSquares(regularized) >= sumSquares(plain) {
+		t.Error("For the sum of squared weights with L2", "Expected less than", sumSquares(plain), "Got", sumSquares(regularized))
+	}
+
+	//the biases are left alone by default
+	regularized.ZeroGrads()
+	regularized.BackwardRegularization()
+	for _, g := range regularized.OutputLayer.BiasGrads {
+		if g != 0 {
+			t.Error("For the bias gradient of the penalty without RegularizeBiases", "Expected", 0, "Got", g)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package neuralnet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/jyakimischak/neuralnet/actfuncs"
+)
+
+// maxLayerParams is the most weights a saved layer can have, Load and LoadBinary refuse a layer with more before
+// allocating anything for it.
+const maxLayerParams = math.MaxInt32
+
+// saveVersion is the version of the saved network schema, it is raised whenever the schema changes so Load can
+// refuse a network it would read wrong.
+const saveVersion = 1
+
+// savedNetwork is what Save writes.  Layers holds every layer in order, the first is the input layer and the last is
+// the output layer.  The links between the layers are not saved, they follow from the order.
+type savedNetwork struct {
+	Version int
+	Layers  []savedLayer
+}
+
+// savedLayer is the architecture and parameters of one layer.  Dense layers have Weights and Biases, normalization
+// layers have Gamma and Beta, and BatchNorm also has RunningMean and RunningVar.
+type savedLayer struct {
+	Kind             string
+	NumNeurons       int
+	NumInputs        int
+	ActFunc          string
+	ActAlpha         float64   `json:",omitempty"`
+	Weights          []float64 `json:",omitempty"`
+	Biases           []float64 `json:",omitempty"`
+	Gamma            []float64 `json:",omitempty"`
+	Beta             []float64 `json:",omitempty"`
+	RunningMean      []float64 `json:",omitempty"`
+	RunningVar       []float64 `json:",omitempty"`
+	L1               float64   `json:",omitempty"`
+	L2               float64   `json:",omitempty"`
+	RegularizeBiases bool      `json:",omitempty"`
+	Dropout          float64   `json:",omitempty"`
+}
+
+// Save will write the architecture, activation functions and parameters of the network to w as JSON, which Load
+// reads back.  The gradients, Mode and the state of the last Calc are not saved.
+func (nn *NeuralNetwork) Save(w io.Writer) error {
+	saved, err := nn.saved()
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(saved)
+}
+
+// Load will read a network written by Save.  The options are applied to the network as they are by NewNeuralNetwork.
+// An error is returned if the version of the saved network is not supported, an activation function it uses is not
+// registered or the network is not valid.
+func Load(r io.Reader, options ...Option) (*NeuralNetwork, error) {
+	var saved savedNetwork
+	err := json.NewDecoder(r).Decode(&saved)
+	if err != nil {
+		return nil, err
+	}
+	return newSavedNeuralNetwork(saved, options...)
+}
+
+// saved will return the architecture and parameters of the network.  The slices are shared with the network.
+func (nn *NeuralNetwork) saved() (savedNetwork, error) {
+	if nn.InputLayer == nil || nn.OutputLayer == nil {
+		return savedNetwork{}, errors.New("Invalid neural network. Did you call NewNeuralNetwork when getting the instance?")
+	}
+	isValid, invalidMsg := nn.IsValid()
+	if !isValid {
+		return savedNetwork{}, errors.New(invalidMsg)
+	}
+	saved := savedNetwork{Version: saveVersion}
+	for layer := nn.InputLayer; layer != nil; layer = layer.NextLayer {
+		saved.Layers = append(saved.Layers, savedLayer{
+			Kind:             layer.Kind,
+			NumNeurons:       layer.NumNeurons,
+			NumInputs:        layer.NumInputs,
+			ActFunc:          layer.ActFunc,
+			ActAlpha:         layer.ActAlpha,
+			Weights:          layer.Weights,
+			Biases:           layer.Biases,
+			Gamma:            layer.Gamma,
+			Beta:             layer.Beta,
+			RunningMean:      layer.RunningMean,
+			RunningVar:       layer.RunningVar,
+			L1:               layer.L1,
+			L2:               layer.L2,
+			RegularizeBiases: layer.RegularizeBiases,
+			Dropout:          layer.Dropout,
+		})
+	}
+	return saved, nil
+}
+
+// newSavedNeuralNetwork will build the network described by saved, using its slices for the parameters, and check
+// that it is valid.
+func newSavedNeuralNetwork(saved savedNetwork, options ...Option) (*NeuralNetwork, error) {
+	if saved.Version != saveVersion {
+		return nil, fmt.Errorf("Saved network version must be %d and is: %d", saveVersion, saved.Version)
+	}
+	if len(saved.Layers) < 2 {
+		return nil, fmt.Errorf("A saved network must have at least 2 layers and has: %d", len(saved.Layers))
+	}
+
+	nn := newEmptyNeuralNetwork(options)
+
+	var prevLayer *neuralLayer
+	for iLayer, sl := range saved.Layers {
+		layerType := layerTypeHidden
+		switch iLayer {
+		case 0:
+			layerType = layerTypeInput
+		case len(saved.Layers) - 1:
+			layerType = layerTypeOutput
+		}
+		layer, err := newSavedLayer(layerType, sl)
+		if err != nil {
+			return nil, fmt.Errorf("Layers[%d]: %v", iLayer, err)
+		}
+
+		layer.PrevLayer = prevLayer
+		if prevLayer != nil {
+			prevLayer.NextLayer = layer
+		}
+		switch layerType {
+		case layerTypeInput:
+			nn.InputLayer = layer
+		case layerTypeHidden:
+			nn.HiddenLayers = append(nn.HiddenLayers, layer)
+		case layerTypeOutput:
+			nn.OutputLayer = layer
+		}
+		prevLayer = layer
+	}
+
+	isValid, invalidMsg := nn.IsValid()
+	if !isValid {
+		return nil, errors.New(invalidMsg)
+	}
+	return nn, nil
+}
+
+// checkSavedLayer will return an error if the sizes of sl are out of range or do not match its parameters.  It is
+// called before anything is allocated for the layer, so a saved network can not make Load allocate more than the
+// parameters it holds.
+func checkSavedLayer(sl savedLayer) error {
+	if sl.NumNeurons < 1 || sl.NumInputs < 1 || sl.NumNeurons > maxLayerParams || sl.NumInputs > maxLayerParams ||
+		uint64(sl.NumNeurons)*uint64(sl.NumInputs) > maxLayerParams {
+		return fmt.Errorf("A layer can not have %d neurons and %d inputs", sl.NumNeurons, sl.NumInputs)
+	}
+	if !actfuncs.IsValidActFunc(sl.ActFunc) {
+		return fmt.Errorf("Unknown activation function: %s", sl.ActFunc)
+	}
+	if !isValidActAlpha(sl.ActAlpha) {
+		return fmt.Errorf("ActAlpha must be >= 0 and is: %f", sl.ActAlpha)
+	}
+	if isNormLayerKind(sl.Kind) {
+		if sl.NumInputs != sl.NumNeurons {
+			return fmt.Errorf("A %s layer must have NumInputs == NumNeurons: %d, %d", sl.Kind, sl.NumInputs, sl.NumNeurons)
+		}
+		if len(sl.Gamma) != sl.NumNeurons || len(sl.Beta) != sl.NumNeurons {
+			return fmt.Errorf("len(Gamma) and len(Beta) must be %d and are: %d, %d", sl.NumNeurons, len(sl.Gamma), len(sl.Beta))
+		}
+		if sl.Kind == LayerKindBatchNorm && (len(sl.RunningMean) != sl.NumNeurons || len(sl.RunningVar) != sl.NumNeurons) {
+			return fmt.Errorf("len(RunningMean) and len(RunningVar) must be %d and are: %d, %d", sl.NumNeurons, len(sl.RunningMean), len(sl.RunningVar))
+		}
+		return nil
+	}
+	if sl.Kind != LayerKindDense {
+		return fmt.Errorf("Unknown layer kind: %s", sl.Kind)
+	}
+	if len(sl.Weights) != sl.NumNeurons*sl.NumInputs || len(sl.Biases) != sl.NumNeurons {
+		return fmt.Errorf("len(Weights) and len(Biases) must be %d and %d and are: %d, %d", sl.NumNeurons*sl.NumInputs, sl.NumNeurons, len(sl.Weights), len(sl.Biases))
+	}
+	return nil
+}
+
+// newSavedLayer will return a layer of the given type with the architecture and parameters of sl, using its slices
+// for the parameters.
+func newSavedLayer(layerType string, sl savedLayer) (*neuralLayer, error) {
+	err := checkSavedLayer(sl)
+	if err != nil {
+		return nil, err
+	}
+	if layerType != layerTypeOutput && sl.ActFunc == actfuncs.Softmax {
+		return nil, fmt.Errorf("ActFunc can not be %s, it is only supported on the output layer", actfuncs.Softmax)
+	}
+	var layer *neuralLayer
+	if isNormLayerKind(sl.Kind) {
+		if layerType != layerTypeHidden {
+			return nil, fmt.Errorf("A %s layer must be a hidden layer", sl.Kind)
+		}
+		layer = newNormLayer(sl.Kind, sl.NumNeurons, sl.ActFunc)
+		layer.Gamma = sl.Gamma
+		layer.Beta = sl.Beta
+		if sl.Kind == LayerKindBatchNorm {
+			layer.RunningMean = sl.RunningMean
+			layer.RunningVar = sl.RunningVar
+		}
+	} else {
+		layer = newDenseLayer(layerType, sl.NumNeurons, sl.NumInputs, sl.ActFunc, sl.Weights, sl.Biases)
+	}
+
+	if sl.ActAlpha != 0 {
+		layer.ActAlpha = sl.ActAlpha
+	}
+	layer.L1 = sl.L1
+	layer.L2 = sl.L2
+	layer.RegularizeBiases = sl.RegularizeBiases
+	if sl.Dropout != 0 {
+		if layerType != layerTypeHidden {
+			return nil, errors.New("Only hidden layers can have Dropout")
+		}
+		layer.Dropout = sl.Dropout
+		layer.DropoutMask = make([]float64, layer.NumNeurons)
+	}
+	if layer.L1 < 0 || layer.L2 < 0 {
+		return nil, fmt.Errorf("L1 and L2 must be >= 0 and are: %f, %f", layer.L1, layer.L2)
+	}
+	return layer, nil
+}
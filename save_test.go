@@ -0,0 +1,197 @@
+package neuralnet
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/jyakimischak/neuralnet/actfuncs"
+	"github.com/jyakimischak/neuralnet/initializers"
+	"github.com/jyakimischak/neuralnet/losses"
+	"github.com/jyakimischak/neuralnet/optimizers"
+)
+
+// newSaveTestNeuralNetwork will return a seeded network that uses every kind of layer and the per-layer settings that
+// are saved, trained for a few epochs so the BatchNorm running statistics and Gamma and Beta have moved.
+func newSaveTestNeuralNetwork(t *testing.T) *NeuralNetwork {
+	nn, err := NewNeuralNetwork(
+		InputLayerProps{NumInputs: 2},
+		[]HiddenLayerProps{
+			HiddenLayerProps{NumNeurons: 4, ActFunc: actfuncs.Tanh, WeightInit: initializers.XavierNormal, L2: 0.01},
+			HiddenLayerProps{Kind: LayerKindBatchNorm, ActFunc: actfuncs.NoActFunc},
+			HiddenLayerProps{NumNeurons: 3, ActFunc: actfuncs.LeakyReLU, ActAlpha: 0.2, WeightInit: initializers.HeNormal, Dropout: 0.25},
+			HiddenLayerProps{Kind: LayerKindLayerNorm, ActFunc: actfuncs.Swish},
+		},
+		OutputLayerProps{NumOutputs: 2, ActFunc: actfuncs.Softmax, L1: 0.001, RegularizeBiases: true},
+		WithSeed(1),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = nn.Fit(Dataset{
+		Inputs:  [][]float64{{0.4, -0.2}, {-0.7, 0.9}, {0.3, 0.8}, {-0.5, -0.6}},
+		Targets: [][]float64{{1, 0}, {0, 1}, {1, 0}, {0, 1}},
+	}, FitConfig{Epochs: 5, Loss: losses.CategoricalCrossEntropy, Optimizer: optimizers.NewAdam(0.01)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return nn
+}
+
+// checkSameNeuralNetwork will report every difference between the architecture and parameters of two networks.
+func checkSameNeuralNetwork(t *testing.T, expected *NeuralNetwork, got *NeuralNetwork) {
+	if len(got.HiddenLayers) != len(expected.HiddenLayers) {
+		t.Fatal("For len(HiddenLayers)", "Expected", len(expected.HiddenLayers), "Got", len(got.HiddenLayers))
+	}
+	gotLayer := got.InputLayer
+	for layer := expected.InputLayer; layer != nil; layer = layer.NextLayer {
+		if gotLayer.LayerType != layer.LayerType || gotLayer.Kind != layer.Kind || gotLayer.ActFunc != layer.ActFunc ||
+			gotLayer.ActAlpha != layer.ActAlpha || gotLayer.NumNeurons != layer.NumNeurons || gotLayer.NumInputs != layer.NumInputs {
+			t.Error("For the architecture of a layer", "Expected", layer.LayerType, layer.Kind, layer.ActFunc, layer.ActAlpha,
+				layer.NumNeurons, layer.NumInputs, "Got", gotLayer.LayerType, gotLayer.Kind, gotLayer.ActFunc, gotLayer.ActAlpha,
+				gotLayer.NumNeurons, gotLayer.NumInputs)
+		}
+		if gotLayer.L1 != layer.L1 || gotLayer.L2 != layer.L2 || gotLayer.RegularizeBiases != layer.RegularizeBiases ||
+			gotLayer.Dropout != layer.Dropout {
+			t.Error("For the settings of a", layer.Kind, "layer", "Expected", layer.L1, layer.L2, layer.RegularizeBiases,
+				layer.Dropout, "Got", gotLayer.L1, gotLayer.L2, gotLayer.RegularizeBiases, gotLayer.Dropout)
+		}
+		expectedState, gotState := layer.state(), gotLayer.state()
+		for i := range expectedState {
+			if len(gotState[i]) != len(expectedState[i]) {
+				t.Error("For the length of parameter", i, "of a", layer.Kind, "layer", "Expected", len(expectedState[i]), "Got", len(gotState[i]))
+				continue
+			}
+			for j := range expectedState[i] {
+				if gotState[i][j] != expectedState[i][j] {
+					t.Error("For parameter", i, j, "of a", layer.Kind, "layer", "Expected", expectedState[i][j], "Got", gotState[i][j])
+				}
+			}
+		}
+		gotLayer = gotLayer.NextLayer
+	}
+}
+
+func TestNeuralNetworkSaveLoad(t *testing.T) {
+	nn := newSaveTestNeuralNetwork(t)
+	var buf bytes.Buffer
+	err := nn.Save(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := buf.String()
+
+	loaded, err := Load(strings.NewReader(saved), WithSeed(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSameNeuralNetwork(t, nn, loaded)
+	inputs := [][]float64{{0.4, -0.2}, {1.5, 0.1}, {-0.3, -0.9}}
+	expected, _ := nn.PredictBatch(inputs)
+	got, err := loaded.PredictBatch(inputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range expected {
+		for j := range expected[i] {
+			if got[i][j] != expected[i][j] {
+				t.Error("For the outputs of the loaded network", "Expected", expected[i], "Got", got[i])
+			}
+		}
+	}
+
+	//saving the loaded network gives the same JSON, and it can be trained further
+	buf.Reset()
+	err = loaded.Save(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != saved {
+		t.Error("For saving the loaded network", "Expected", saved, "Got", buf.String())
+	}
+	_, err = loaded.Fit(Dataset{Inputs: [][]float64{{0.4, -0.2}, {-0.1, 0.3}}, Targets: [][]float64{{1, 0}, {0, 1}}},
+		FitConfig{Epochs: 1, Loss: losses.CategoricalCrossEntropy, Optimizer: optimizers.NewSGD(0.1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//the schema records the version, and the layers without their links
+	var schema map[string]interface{}
+	err = json.Unmarshal([]byte(saved), &schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema["Version"] != float64(saveVersion) || len(schema["Layers"].([]interface{})) != 6 {
+		t.Error("For the saved Version and number of Layers", "Expected", saveVersion, 6, "Got", schema["Version"], len(schema["Layers"].([]interface{})))
+	}
+	if strings.Contains(saved, "PrevLayer") || strings.Contains(saved, "Grads") {
+		t.Error("For the saved JSON", "Expected no links or gradients", "Got", saved)
+	}
+}
+
+func TestNeuralNetworkLoadInvalid(t *testing.T) {
+	nn := newSaveTestNeuralNetwork(t)
+	var buf bytes.Buffer
+	err := nn.Save(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := buf.String()
+
+	cases := []struct {
+		name   string
+		change func(s *savedNetwork)
+	}{
+		{"an unsupported version", func(s *savedNetwork) { s.Version = saveVersion + 1 }},
+		{"no layers", func(s *savedNetwork) { s.Layers = nil }},
+		{"an unknown activation function", func(s *savedNetwork) { s.Layers[1].ActFunc = "invalid" }},
+		{"an unknown layer kind", func(s *savedNetwork) { s.Layers[1].Kind = "invalid" }},
+		{"a missing weight", func(s *savedNetwork) { s.Layers[1].Weights = s.Layers[1].Weights[1:] }},
+		{"a missing Gamma", func(s *savedNetwork) { s.Layers[2].Gamma = nil }},
+		{"a missing RunningVar", func(s *savedNetwork) { s.Layers[2].RunningVar = s.Layers[2].RunningVar[1:] }},
+		{"a layer that does not fit the one before it", func(s *savedNetwork) { s.Layers = append(s.Layers[:1], s.Layers[3:]...) }},
+		{"a normalization output layer", func(s *savedNetwork) { s.Layers = s.Layers[:3] }},
+		{"softmax on a hidden layer", func(s *savedNetwork) { s.Layers[3].ActFunc = actfuncs.Softmax }},
+		{"dropout of 1", func(s *savedNetwork) { s.Layers[3].Dropout = 1 }},
+		{"dropout on the output layer", func(s *savedNetwork) { s.Layers[5].Dropout = 0.5 }},
+		{"a negative L2", func(s *savedNetwork) { s.Layers[1].L2 = -1 }},
+		{"a negative ActAlpha", func(s *savedNetwork) { s.Layers[3].ActAlpha = -1 }},
+		{"a layer whose size overflows", func(s *savedNetwork) { s.Layers[1].NumNeurons, s.Layers[1].NumInputs = 3037000500, 3037000500 }},
+		{"a layer of 1e9 x 1e9", func(s *savedNetwork) { s.Layers[1].NumNeurons, s.Layers[1].NumInputs = 1e9, 1e9 }},
+		{"a layer bigger than its weights", func(s *savedNetwork) { s.Layers[1].NumNeurons = 1 << 20 }},
+		{"a normalization layer bigger than its Gamma", func(s *savedNetwork) { s.Layers[2].NumNeurons, s.Layers[2].NumInputs = 1<<15, 1<<15 }},
+		{"no neurons", func(s *savedNetwork) { s.Layers[1].NumNeurons = 0 }},
+	}
+	for _, c := range cases {
+		var s savedNetwork
+		err = json.Unmarshal([]byte(saved), &s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.change(&s)
+		changed, err := json.Marshal(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = Load(bytes.NewReader(changed))
+		if err == nil {
+			t.Error("For", c.name, "did not recieve error")
+		}
+	}
+
+	//a header with no parameters behind it is refused before anything is allocated for it
+	_, err = Load(strings.NewReader(`{"Version":1,"Layers":[{"Kind":"dense","NumNeurons":3037000500,"NumInputs":3037000500,"ActFunc":"noActFunc"},{"Kind":"dense","NumNeurons":1,"NumInputs":3037000500,"ActFunc":"noActFunc"}]}`))
+	if err == nil {
+		t.Error("For an oversized layer, did not recieve error")
+	}
+
+	_, err = Load(strings.NewReader(saved[:len(saved)/2]))
+	if err == nil {
+		t.Error("For truncated JSON, did not recieve error")
+	}
+	err = (&NeuralNetwork{}).Save(&buf)
+	if err == nil {
+		t.Error("For saving an invalid network, did not recieve error")
+	}
+}
@@ -0,0 +1,232 @@
+/*
+Package schedules defines the learning rate schedules an optimizer can follow during training.
+
+author: Jonas Yakimischak
+*/
+package schedules
+
+import "math"
+
+// Schedule decides the learning rate of an optimizer.  The optimizer queries it on every step, numbered from 0, with
+// its own learning rate as the base rate.
+type Schedule interface {
+	LearningRate(step int, baseRate float64) float64
+}
+
+// Observer is a Schedule that also follows a metric, such as the validation loss, which Fit passes to it at the end
+// of every epoch.  Lower values of the metric are better.
+type Observer interface {
+	Schedule
+	Observe(metric float64)
+}
+
+//*************************************************************************************************************
+//StepDecay
+
+// StepDecay multiplies the learning rate by Gamma every StepSize steps.
+type StepDecay struct {
+	StepSize int
+	Gamma    float64
+}
+
+// NewStepDecay will return a StepDecay schedule.
+func NewStepDecay(stepSize int, gamma float64) *StepDecay {
+	return &StepDecay{StepSize: stepSize, Gamma: gamma}
+}
+
+// LearningRate will return the decayed learning rate for the step.
+func (s *StepDecay) LearningRate(step int, baseRate float64) float64 {
+	if s.StepSize < 1 {
+		return baseRate
+	}
+	return baseRate * math.Pow(s.Gamma, float64(step/s.StepSize))
+}
+
+//*************************************************************************************************************
+//ExponentialDecay
+
+// ExponentialDecay multiplies the learning rate by DecayRate every DecaySteps steps, smoothly rather than all at once.
+type ExponentialDecay struct {
+	DecayRate  float64
+	DecaySteps int
+}
+
+// NewExponentialDecay will return an ExponentialDecay schedule.
+func NewExponentialDecay(decayRate float64, decaySteps int) *ExponentialDecay {
+	return &ExponentialDecay{DecayRate: decayRate, DecaySteps: decaySteps}
+}
+
+// LearningRate will return the decayed learning rate for the step.
+func (s *ExponentialDecay) LearningRate(step int, baseRate float64) float64 {
+	if s.DecaySteps < 1 {
+		return baseRate
+	}
+	return baseRate * math.Pow(s.DecayRate, float64(step)/float64(s.DecaySteps))
+}
+
+//*************************************************************************************************************
+//CosineAnnealing
+
+// CosineAnnealing lowers the learning rate from the base rate to MinRate along a half cosine over Period steps, then
+// restarts from the base rate.  Every restart the period is multiplied by PeriodMult, a PeriodMult below 1 is taken
+// as 1.
+type CosineAnnealing struct {
+	Period     int
+	PeriodMult float64
+	MinRate    float64
+}
+
+// NewCosineAnnealing will return a CosineAnnealing schedule that restarts every period steps.
+func NewCosineAnnealing(period int) *CosineAnnealing {
+	return &CosineAnnealing{Period: period, PeriodMult: 1}
+}
+
+// LearningRate will return the annealed learning rate for the step.
+func (s *CosineAnnealing) LearningRate(step int, baseRate float64) float64 {
+	if s.Period < 1 {
+		return baseRate
+	}
+	stepInPeriod, period := s.findPeriod(float64(step))
+	return s.MinRate + (baseRate-s.MinRate)*(1+math.Cos(math.Pi*stepInPeriod/period))/2
+}
+
+// findPeriod will return the step within the period the step falls in, and the length of that period.
+func (s *CosineAnnealing) findPeriod(step float64) (float64, float64) {
+	period := float64(s.Period)
+	if s.PeriodMult <= 1 {
+		return math.Mod(step, period), period
+	}
+	//period k starts at period * (mult^k - 1) / (mult - 1), so the step is in period
+	//floor(log(1 + step * (mult - 1) / period) / log(mult)), checked against its neighbours for rounding
+	mult := s.PeriodMult
+	k := math.Floor(math.Log1p(step*(mult-1)/period) / math.Log(mult))
+	start := func(k float64) float64 { return period * (math.Pow(mult, k) - 1) / (mult - 1) }
+	if step < start(k) {
+		k--
+	} else if step >= start(k+1) {
+		k++
+	}
+	return step - start(k), period * math.Pow(mult, k)
+}
+
+//*************************************************************************************************************
+//LinearWarmup
+
+// LinearWarmup raises the learning rate in a straight line from base rate / WarmupSteps to the base rate over the
+// first WarmupSteps steps.  After that the learning rate comes from Then, with steps counted from the end of the
+// warmup, or is the base rate if Then is nil.
+type LinearWarmup struct {
+	WarmupSteps int
+	Then        Schedule
+}
+
+// NewLinearWarmup will return a LinearWarmup schedule that hands over to then, which can be nil.
+func NewLinearWarmup(warmupSteps int, then Schedule) *LinearWarmup {
+	return &LinearWarmup{WarmupSteps: warmupSteps, Then: then}
+}
+
+// LearningRate will return the warmed up learning rate for the step.
+func (s *LinearWarmup) LearningRate(step int, baseRate float64) float64 {
+	if step < s.WarmupSteps {
+		return baseRate * float64(step+1) / float64(s.WarmupSteps)
+	}
+	if s.Then == nil {
+		return baseRate
+	}
+	return s.Then.LearningRate(step-s.WarmupSteps, baseRate)
+}
+
+// Observe will pass the metric on to Then if it is an Observer.
+func (s *LinearWarmup) Observe(metric float64) {
+	if observer, ok := s.Then.(Observer); ok {
+		observer.Observe(metric)
+	}
+}
+
+//*************************************************************************************************************
+//OneCycle
+
+// OneCycle is the one cycle policy over TotalSteps steps.  The learning rate rises along a half cosine from
+// base rate / DivFactor to the base rate over the first PctStart of the steps, then falls along a half cosine to
+// base rate / (DivFactor * FinalDivFactor) at the last step, and stays there.
+type OneCycle struct {
+	TotalSteps     int
+	PctStart       float64
+	DivFactor      float64
+	FinalDivFactor float64
+}
+
+// NewOneCycle will return a OneCycle schedule over totalSteps steps that peaks 30% of the way through, starts at 1/25
+// of the base rate and ends at 1/10000 of the starting rate.
+func NewOneCycle(totalSteps int) *OneCycle {
+	return &OneCycle{TotalSteps: totalSteps, PctStart: 0.3, DivFactor: 25, FinalDivFactor: 1e4}
+}
+
+// LearningRate will return the learning rate for the step within the cycle.
+func (s *OneCycle) LearningRate(step int, baseRate float64) float64 {
+	initialRate := baseRate / s.DivFactor
+	finalRate := initialRate / s.FinalDivFactor
+	lastStep := float64(s.TotalSteps - 1)
+	peakStep := s.PctStart * lastStep
+	switch {
+	case float64(step) >= lastStep:
+		return finalRate
+	case float64(step) < peakStep:
+		return cosineBetween(initialRate, baseRate, float64(step)/peakStep)
+	default:
+		return cosineBetween(baseRate, finalRate, (float64(step)-peakStep)/(lastStep-peakStep))
+	}
+}
+
+// cosineBetween will return the value the fraction of the way from start to end along a half cosine.
+func cosineBetween(start float64, end float64, fraction float64) float64 {
+	return end + (start-end)*(1+math.Cos(math.Pi*fraction))/2
+}
+
+//*************************************************************************************************************
+//ReduceOnPlateau
+
+// ReduceOnPlateau multiplies the learning rate by Factor each time the observed metric, normally the validation loss,
+// has not improved by more than MinDelta for Patience observations in a row, but never takes it below MinRate.
+type ReduceOnPlateau struct {
+	Factor   float64
+	Patience int
+	MinDelta float64
+	MinRate  float64
+
+	reductions         int
+	best               float64
+	hasBest            bool
+	numBadObservations int
+}
+
+// NewReduceOnPlateau will return a ReduceOnPlateau schedule that halves the learning rate after patience observations
+// without improvement.
+func NewReduceOnPlateau(patience int) *ReduceOnPlateau {
+	return &ReduceOnPlateau{Factor: 0.5, Patience: patience}
+}
+
+// LearningRate will return the base rate reduced once for every plateau observed so far.
+func (s *ReduceOnPlateau) LearningRate(step int, baseRate float64) float64 {
+	return math.Max(baseRate*math.Pow(s.Factor, float64(s.reductions)), s.MinRate)
+}
+
+// Observe will record the metric and reduce the learning rate if it has stopped improving.
+func (s *ReduceOnPlateau) Observe(metric float64) {
+	if !s.hasBest || metric < s.best-s.MinDelta {
+		s.best = metric
+		s.hasBest = true
+		s.numBadObservations = 0
+		return
+	}
+	s.numBadObservations++
+	if s.numBadObservations >= s.Patience {
+		s.reductions++
+		s.numBadObservations = 0
+	}
+}
+
+// Reductions will return the number of times the learning rate has been reduced.
+func (s *ReduceOnPlateau) Reductions() int {
+	return s.reductions
+}
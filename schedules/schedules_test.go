@@ -0,0 +1,81 @@
+package schedules
+
+import (
+	"math"
+	"testing"
+)
+
+// checkRates will report an error for every step whose learning rate from the schedule is not within 1e-12 of
+// expected, with a base rate of 1.
+func checkRates(t *testing.T, name string, s Schedule, expected map[int]float64) {
+	for step, e := range expected {
+		got := s.LearningRate(step, 1)
+		if math.Abs(got-e) > 1e-12 {
+			t.Error("For", name, "step", step, "Expected", e, "Got", got)
+		}
+	}
+}
+
+func TestStepDecay(t *testing.T) {
+	checkRates(t, "StepDecay", NewStepDecay(10, 0.5), map[int]float64{0: 1, 9: 1, 10: 0.5, 25: 0.25})
+	checkRates(t, "StepDecay with StepSize 0", &StepDecay{}, map[int]float64{0: 1, 100: 1})
+}
+
+func TestExponentialDecay(t *testing.T) {
+	checkRates(t, "ExponentialDecay", NewExponentialDecay(0.5, 10), map[int]float64{0: 1, 5: math.Sqrt(0.5), 10: 0.5, 20: 0.25})
+}
+
+func TestCosineAnnealing(t *testing.T) {
+	s := NewCosineAnnealing(10)
+	s.MinRate = 0.1
+	checkRates(t, "CosineAnnealing", s, map[int]float64{0: 1, 5: 0.55, 10: 1, 15: 0.55})
+
+	//with warm restarts after 10, then 20, then 40 steps
+	s.PeriodMult = 2
+	checkRates(t, "CosineAnnealing with PeriodMult 2", s, map[int]float64{9: 0.1 + 0.9*(1+math.Cos(math.Pi*0.9))/2, 10: 1, 20: 0.55, 30: 1, 50: 0.55})
+	checkRates(t, "CosineAnnealing with PeriodMult 2", s, map[int]float64{70: 1, 149: 0.1 + 0.9*(1+math.Cos(math.Pi*79/80))/2, 150: 1})
+
+	//the period is found without stepping through every one before it
+	s.PeriodMult = 1
+	checkRates(t, "CosineAnnealing at a late step", s, map[int]float64{1e15: 1, 1e15 + 5: 0.55})
+	s.PeriodMult = 1.5
+	checkRates(t, "CosineAnnealing with PeriodMult 1.5", s, map[int]float64{10: 1, 25: 1, 47: 0.55 + 0.45*math.Cos(math.Pi*22/22.5)})
+}
+
+func TestLinearWarmup(t *testing.T) {
+	checkRates(t, "LinearWarmup", NewLinearWarmup(4, nil), map[int]float64{0: 0.25, 1: 0.5, 3: 1, 100: 1})
+	checkRates(t, "LinearWarmup then StepDecay", NewLinearWarmup(4, NewStepDecay(10, 0.5)), map[int]float64{3: 1, 4: 1, 14: 0.5})
+
+	//observations reach a schedule after the warmup
+	plateau := NewReduceOnPlateau(1)
+	warmup := NewLinearWarmup(4, plateau)
+	warmup.Observe(1)
+	warmup.Observe(1)
+	if plateau.Reductions() != 1 {
+		t.Error("For Reductions after observing through LinearWarmup", "Expected", 1, "Got", plateau.Reductions())
+	}
+}
+
+func TestOneCycle(t *testing.T) {
+	s := NewOneCycle(101)
+	checkRates(t, "OneCycle", s, map[int]float64{0: 1.0 / 25, 30: 1, 100: 1.0 / 25 / 1e4, 200: 1.0 / 25 / 1e4})
+	//half way up and half way down
+	checkRates(t, "OneCycle", s, map[int]float64{15: (1 + 1.0/25) / 2, 65: (1 + 1.0/25/1e4) / 2})
+}
+
+func TestReduceOnPlateau(t *testing.T) {
+	s := NewReduceOnPlateau(2)
+	s.MinRate = 0.2
+	metrics := []float64{1, 0.9, 0.95, 0.9, 0.89, 0.89, 0.89, 0.89, 0.89, 0.89}
+	//the learning rate after each observation
+	expected := []float64{1, 1, 1, 0.5, 0.5, 0.5, 0.25, 0.25, 0.2, 0.2}
+	for i, metric := range metrics {
+		s.Observe(metric)
+		got := s.LearningRate(i, 1)
+		if math.Abs(got-expected[i]) > 1e-12 {
+			t.Error("For ReduceOnPlateau after observation", i, "Expected", expected[i], "Got", got)
+		}
+	}
+
+	var _ Observer = s
+}
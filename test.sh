@@ -1,4 +1,8 @@
 go test github.com/jyakimischak/neuralnet/actfuncs
-go test github.com/jyakimischak/neuralnet
+go test github.com/jyakimischak/neuralnet/initializers
+go test github.com/jyakimischak/neuralnet/losses
+go test github.com/jyakimischak/neuralnet/optimizers
+go test github.com/jyakimischak/neuralnet/schedules
+go test -race github.com/jyakimischak/neuralnet
 
 